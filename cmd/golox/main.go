@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"golox"
+	"golox/interpreter"
+	"os"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) >= 2 {
+		runFile(os.Args[1], os.Args[2:])
+	} else {
+		runPrompt()
+	}
+}
+
+// runPrompt reads lines from the same buffered reader that the input
+// natives use, so that a line read by the script isn't also run as code.
+func runPrompt() {
+	stdin := bufio.NewReader(os.Stdin)
+	vm := golox.New(golox.Options{Capabilities: interpreter.AllCapabilities(), Stdin: stdin})
+
+	for {
+		fmt.Print("> ")
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			break
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if path, ok := strings.CutPrefix(line, ":save "); ok {
+			vm.Report(vm.SaveSnapshot(strings.TrimSpace(path)))
+			continue
+		}
+		if path, ok := strings.CutPrefix(line, ":load "); ok {
+			vm.Report(vm.LoadSnapshot(strings.TrimSpace(path)))
+			continue
+		}
+		_, err = vm.Eval(line)
+		var exitError *golox.ExitError
+		if errors.As(err, &exitError) {
+			os.Exit(exitError.Code)
+		}
+		vm.Report(err)
+	}
+}
+
+// runFile runs the script at path with args as os.args. A script that
+// calls exit ends the process with its status; otherwise compile errors
+// exit with 65, runtime errors with 70 and an unreadable file with 66.
+func runFile(path string, args []string) {
+	vm := golox.New(golox.Options{Capabilities: interpreter.AllCapabilities(), Args: args})
+	err := vm.RunFile(path)
+	vm.Report(err)
+
+	var exitError *golox.ExitError
+	var loxError *golox.Error
+	if errors.As(err, &exitError) {
+		os.Exit(exitError.Code)
+	} else if errors.As(err, &loxError) {
+		if loxError.Diagnostics.HadError() {
+			os.Exit(65)
+		}
+		os.Exit(70)
+	} else if err != nil {
+		os.Exit(66)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 type Expr interface {
 	Accept(v Visitor) Object
+	Span() token.Span
 }
 
 type Visitor interface {
@@ -27,107 +28,167 @@ type Visitor interface {
 type Assign struct {
 	Name  token.Token
 	Value Expr
+	Range token.Span
 }
 
 func (a *Assign) Accept(v Visitor) Object {
 	return v.VisitAssignExpr(a)
 }
 
+func (a *Assign) Span() token.Span {
+	return a.Range
+}
+
 type Binary struct {
 	Left     Expr
 	Operator token.Token
 	Right    Expr
+	Range    token.Span
 }
 
 func (b *Binary) Accept(v Visitor) Object {
 	return v.VisitBinaryExpr(b)
 }
 
+func (b *Binary) Span() token.Span {
+	return b.Range
+}
+
 type Call struct {
 	Callee    Expr
 	Paren     token.Token
 	Arguments []Expr
+	Range     token.Span
 }
 
 func (c *Call) Accept(v Visitor) Object {
 	return v.VisitCallExpr(c)
 }
 
+func (c *Call) Span() token.Span {
+	return c.Range
+}
+
 type Get struct {
 	Object Expr
 	Name   token.Token
+	Range  token.Span
 }
 
 func (g *Get) Accept(v Visitor) Object {
 	return v.VisitGetExpr(g)
 }
 
+func (g *Get) Span() token.Span {
+	return g.Range
+}
+
 type Grouping struct {
 	Expression Expr
+	Range      token.Span
 }
 
 func (g *Grouping) Accept(v Visitor) Object {
 	return v.VisitGroupingExpr(g)
 }
 
+func (g *Grouping) Span() token.Span {
+	return g.Range
+}
+
 type Literal struct {
 	Value Object
+	Range token.Span
 }
 
 func (l *Literal) Accept(v Visitor) Object {
 	return v.VisitLiteralExpr(l)
 }
 
+func (l *Literal) Span() token.Span {
+	return l.Range
+}
+
 type Logical struct {
 	Left     Expr
 	Operator token.Token
 	Right    Expr
+	Range    token.Span
 }
 
 func (l *Logical) Accept(v Visitor) Object {
 	return v.VisitLogicalExpr(l)
 }
 
+func (l *Logical) Span() token.Span {
+	return l.Range
+}
+
 type Set struct {
 	Object Expr
 	Name   token.Token
 	Value  Expr
+	Range  token.Span
 }
 
 func (s *Set) Accept(v Visitor) Object {
 	return v.VisitSetExpr(s)
 }
 
+func (s *Set) Span() token.Span {
+	return s.Range
+}
+
 type Super struct {
 	Keyword token.Token
 	Method  token.Token
+	Range   token.Span
 }
 
 func (s *Super) Accept(v Visitor) Object {
 	return v.VisitSuperExpr(s)
 }
 
+func (s *Super) Span() token.Span {
+	return s.Range
+}
+
 type This struct {
 	Keyword token.Token
+	Range   token.Span
 }
 
 func (t *This) Accept(v Visitor) Object {
 	return v.VisitThisExpr(t)
 }
 
+func (t *This) Span() token.Span {
+	return t.Range
+}
+
 type Unary struct {
 	Operator token.Token
 	Right    Expr
+	Range    token.Span
 }
 
 func (u *Unary) Accept(v Visitor) Object {
 	return v.VisitUnaryExpr(u)
 }
 
+func (u *Unary) Span() token.Span {
+	return u.Range
+}
+
 type Variable struct {
-	Name token.Token
+	Name  token.Token
+	Range token.Span
 }
 
 func (va *Variable) Accept(v Visitor) Object {
 	return v.VisitVariableExpr(va)
 }
+
+func (va *Variable) Span() token.Span {
+	return va.Range
+}
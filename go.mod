@@ -1 +1,3 @@
 module golox
+
+go 1.22
@@ -0,0 +1,319 @@
+// Package golox embeds the Lox interpreter in Go programs.
+package golox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golox/interpreter"
+	"golox/object"
+	"golox/parser"
+	"golox/resolver"
+	"golox/rt"
+	"golox/scan"
+	"golox/stmt"
+	"golox/token"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures a VM.
+type Options struct {
+	// Stdout, Stderr and Stdin replace the process streams for print,
+	// error reports and input. Nil leaves the process stream in place.
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// MaxSteps bounds the loop iterations and calls made by each Eval,
+	// RunFile or Call; zero means no limit. Timeout bounds their wall-clock
+	// time, and cancelling Context stops them early.
+	MaxSteps uint64
+	Timeout  time.Duration
+	Context  context.Context
+
+	// MaxCallDepth bounds recursion; zero keeps
+	// interpreter.DefaultMaxCallDepth.
+	MaxCallDepth int
+
+	// MaxMemory bounds the approximate bytes each run may allocate, less
+	// the scopes it has since left; zero means no limit.
+	MaxMemory uint64
+
+	// Capabilities grants access to the host, such as the file system or
+	// the clock. Nil grants nothing.
+	Capabilities *interpreter.Capabilities
+
+	// Args are the script arguments listed by os.args.
+	Args []string
+}
+
+// VM runs Lox programs. Globals defined by one call to Eval or RunFile are
+// visible to the next, as in the REPL. VMs share no Lox state and may run
+// in parallel, but each VM must be used by one goroutine at a time. The
+// exceptions are process-wide: environment variables set with os.setEnv,
+// and the working directory behind os.cwd and relative fs paths.
+type VM struct {
+	interpreter *interpreter.Interpreter
+	timeout     time.Duration
+
+	// host holds the globals provided by New and Bind, which Snapshot
+	// leaves out. declarations and order record the source of top-level
+	// classes and functions so that Snapshot can write them.
+	host         map[string]object.Object
+	declarations map[stmt.Stmt]declaration
+	order        []stmt.Stmt
+
+	// sources keeps the text of each Eval that declared a function or
+	// class, since code from it may run, and fail, in a later Eval.
+	sources    rt.Sources
+	nextSource uint
+}
+
+func New(opts Options) *VM {
+	i := interpreter.NewInterpreter()
+	if opts.Stdout != nil {
+		i.Stdout = opts.Stdout
+	}
+	if opts.Stderr != nil {
+		i.Stderr = opts.Stderr
+	}
+	if opts.Stdin != nil {
+		i.Stdin = opts.Stdin
+	}
+	i.MaxSteps = opts.MaxSteps
+	i.Context = opts.Context
+	if opts.MaxCallDepth > 0 {
+		i.MaxCallDepth = opts.MaxCallDepth
+	}
+	i.MaxMemory = opts.MaxMemory
+	i.Capabilities = opts.Capabilities
+	i.SetArgs(opts.Args)
+	vm := &VM{
+		interpreter:  i,
+		timeout:      opts.Timeout,
+		host:         make(map[string]object.Object),
+		declarations: make(map[stmt.Stmt]declaration),
+		sources:      make(rt.Sources),
+	}
+	for _, name := range i.Globals.Names() {
+		vm.host[name], _ = i.Globals.Lookup(name)
+	}
+	return vm
+}
+
+// begin resets the execution limits before a run.
+func (vm *VM) begin() {
+	vm.interpreter.ResetSteps()
+	vm.interpreter.ResetStats()
+	if vm.timeout > 0 {
+		vm.interpreter.Deadline = time.Now().Add(vm.timeout)
+	}
+}
+
+// Error reports the problems that stopped a program from scanning,
+// parsing, resolving or running to completion. Source is the program that
+// failed; a runtime error may point into code from an earlier Eval, such
+// as a function it called.
+type Error struct {
+	Source      string
+	Diagnostics *rt.Diagnostics
+
+	// sources holds the text behind every span the diagnostics may use.
+	sources rt.Sources
+}
+
+// Unwrap exposes the Go errors behind runtime errors, such as
+// rt.ErrStepLimit, to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	causes := make([]error, 0)
+	for _, item := range e.Diagnostics.Items {
+		if item.Cause != nil {
+			causes = append(causes, item.Cause)
+		}
+	}
+	return causes
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Diagnostics.Items))
+	for _, item := range e.Diagnostics.Items {
+		messages = append(messages, item.String())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Print writes the diagnostics to w, quoting the offending source lines.
+func (e *Error) Print(w io.Writer) {
+	e.Diagnostics.Print(w, e.sources)
+}
+
+// ExitError reports that the script called exit. It is returned even for
+// status zero, so hosts can tell that the script asked to stop.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return "exit status " + strconv.Itoa(e.Code)
+}
+
+// exited returns an *ExitError if the last run ended by calling exit.
+func (vm *VM) exited() error {
+	if code, ok := vm.interpreter.Exited(); ok {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// Stats returns the allocation statistics of the most recent run.
+func (vm *VM) Stats() interpreter.Stats {
+	return vm.interpreter.Stats()
+}
+
+// Report writes err to the VM's error stream, quoting the offending source
+// lines when err is an *Error. An *ExitError is not reported.
+func (vm *VM) Report(err error) {
+	var loxError *Error
+	var exitError *ExitError
+	if errors.As(err, &exitError) {
+		return
+	} else if errors.As(err, &loxError) {
+		loxError.Print(vm.interpreter.Stderr)
+	} else if err != nil {
+		_, _ = fmt.Fprintln(vm.interpreter.Stderr, err)
+	}
+}
+
+// Eval runs source. If the program ends with an expression statement, the
+// value of that expression is returned.
+func (vm *VM) Eval(source string) (object.Object, error) {
+	vm.nextSource++
+	id := vm.nextSource
+	diagnostics := rt.NewDiagnostics()
+	fail := func() (object.Object, error) {
+		sources := maps.Clone(vm.sources)
+		sources[id] = source
+		return nil, &Error{Source: source, Diagnostics: diagnostics, sources: sources}
+	}
+
+	tokens, scanDiagnostics := scan.NewScanner(source).SourceID(id).ScanTokens()
+	diagnostics.Append(scanDiagnostics)
+	for _, t := range tokens {
+		if t.Type == token.Fun || t.Type == token.Class {
+			vm.sources[id] = source
+			break
+		}
+	}
+	statements, parseDiagnostics := parser.NewParser(tokens).Parse()
+	diagnostics.Append(parseDiagnostics)
+	if diagnostics.HadError() {
+		return fail()
+	}
+
+	diagnostics.Append(resolver.NewResolver(vm.interpreter).Resolve(statements))
+	if diagnostics.HadError() {
+		return fail()
+	}
+
+	vm.record(source, statements)
+	vm.begin()
+	var last *stmt.Expression
+	if n := len(statements); n > 0 {
+		if expression, ok := statements[n-1].(*stmt.Expression); ok {
+			last = expression
+			statements = statements[:n-1]
+		}
+	}
+
+	diagnostics.Append(vm.interpreter.Interpret(statements))
+	if err := vm.exited(); err != nil {
+		return nil, err
+	}
+	if diagnostics.HadRuntimeError() {
+		return fail()
+	}
+	if last == nil {
+		return nil, nil
+	}
+
+	value, evalDiagnostics := vm.interpreter.Evaluate(last.Expression)
+	diagnostics.Append(evalDiagnostics)
+	if err := vm.exited(); err != nil {
+		return nil, err
+	}
+	if diagnostics.HadRuntimeError() {
+		return fail()
+	}
+	return value, nil
+}
+
+// RunFile reads the script at path and runs it.
+func (vm *VM) RunFile(path string) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = vm.Eval(string(bytes))
+	return err
+}
+
+// Call invokes the global function or class called name.
+func (vm *VM) Call(name string, args ...object.Object) (object.Object, error) {
+	value, ok := vm.Get(name)
+	if !ok {
+		return nil, &Error{Diagnostics: runtimeError(rt.CodeUndefinedVariable, "Undefined variable '"+name+"'.")}
+	}
+
+	function, ok := value.(interpreter.LoxCallable)
+	if !ok {
+		return nil, &Error{Diagnostics: runtimeError(rt.CodeNotCallable, "Can only call functions and classes.")}
+	}
+
+	vm.begin()
+	result, diagnostics := vm.interpreter.CallFunction(function, args)
+	if err := vm.exited(); err != nil {
+		return nil, err
+	}
+	if diagnostics.HadRuntimeError() {
+		return nil, &Error{Diagnostics: diagnostics, sources: maps.Clone(vm.sources)}
+	}
+	return result, nil
+}
+
+// Get returns the value of the global variable called name.
+func (vm *VM) Get(name string) (object.Object, bool) {
+	return vm.interpreter.Globals.Lookup(name)
+}
+
+// Set defines or replaces the global variable called name.
+func (vm *VM) Set(name string, value object.Object) {
+	vm.interpreter.Globals.Define(name, value)
+}
+
+// Bind defines a global holding a Go value. Functions become natives that
+// convert their arguments and results, and struct pointers become objects
+// whose exported fields and methods are properties.
+func (vm *VM) Bind(name string, value any) error {
+	bound, err := interpreter.Bind(value)
+	if err != nil {
+		return err
+	}
+	if native, ok := bound.(*interpreter.Native); ok {
+		native.Name = name
+	}
+	vm.Set(name, bound)
+	vm.host[name] = bound
+	return nil
+}
+
+func runtimeError(code rt.Code, message string) *rt.Diagnostics {
+	diagnostics := rt.NewDiagnostics()
+	diagnostics.ErrorRuntime(rt.RuntimeError{Code: code, Message: message})
+	return diagnostics
+}
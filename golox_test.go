@@ -0,0 +1,135 @@
+package golox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"golox/object"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The program each VM in TestParallelVMs runs. It touches the shared
+// pieces of the interpreter: classes, closures, collections, string and
+// json natives, and the module namespaces.
+const parallelProgram = `
+class Counter {
+  init(start) { this.count = start; }
+  next() { this.count = this.count + 1; return this.count; }
+}
+
+fun adder(n) {
+  fun add(x) { return x + n; }
+  return add;
+}
+
+var counter = Counter(id);
+var add = adder(id);
+var items = List();
+var seen = Map();
+for (var i = 0; i < 200; i = i + 1) {
+  items.push(add(counter.next()));
+  seen.set(sprintf("%d", i), i * id);
+}
+var total = 0;
+for (var i = 0; i < items.len(); i = i + 1) {
+  total = total + items.get(i);
+}
+print total;
+print seen.get("199");
+print json.stringify(json.parse(json.stringify(seen)).get("10"));
+print sprintf("%05d|%s", id, sprintf("vm%d", id));
+print math.floor(math.sqrt(id * id));
+`
+
+// TestParallelVMs runs many VMs at once and checks that each printed only
+// its own results. Run it with -race to check that VMs share no state.
+func TestParallelVMs(t *testing.T) {
+	const vms = 32
+
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, vms)
+	errs := make([]error, vms)
+	for n := range vms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vm := New(Options{Stdout: &outputs[n]})
+			if _, err := vm.Eval(fmt.Sprintf("var id = %d;", n+1)); err != nil {
+				errs[n] = err
+				return
+			}
+			for range 3 {
+				if _, err := vm.Eval(parallelProgram); err != nil {
+					errs[n] = err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for n := range vms {
+		if errs[n] != nil {
+			t.Errorf("vm %d: %v", n+1, errs[n])
+			continue
+		}
+		id := n + 1
+		// counter.next() yields id+1 to id+200, each plus id.
+		total := 200*id + 200*id + 200*201/2
+		want := fmt.Sprintf("%d\n%d\n%d\n%05d|vm%d\n%d\n", total, 199*id, 10*id, id, id, id)
+		want = want + want + want
+		if got := outputs[n].String(); got != want {
+			t.Errorf("vm %d printed:\n%s\nwant:\n%s", id, got, want)
+		}
+	}
+}
+
+func TestBoundFunctionPanic(t *testing.T) {
+	vm := New(Options{})
+	if err := vm.Bind("boom", func() { panic("x") }); err != nil {
+		t.Fatal(err)
+	}
+	_, err := vm.Eval("boom();")
+	if err == nil || !strings.Contains(err.Error(), "Go function panicked: x.") {
+		t.Errorf("got error %v, want the panic as a runtime error", err)
+	}
+	if value, err := vm.Eval("1 + 1;"); err != nil || value != object.Number(2) {
+		t.Errorf("VM unusable after the panic: %v, %v", value, err)
+	}
+}
+
+// A call from the host must not be reported as made from wherever the last
+// failed call in a script was.
+func TestHostCallHasNoCallSite(t *testing.T) {
+	vm := New(Options{})
+	_, err := vm.Eval(`
+fun f(n) {
+  return 1 + n;
+}
+f("x");
+`)
+	if err == nil {
+		t.Fatal("expected an error from f(\"x\")")
+	}
+
+	_, err = vm.Call("f", object.String("y"))
+	var loxErr *Error
+	if !errors.As(err, &loxErr) {
+		t.Fatalf("got %v, want an *Error", err)
+	}
+	for _, frame := range loxErr.Diagnostics.Items[0].Trace {
+		if frame.Site.Line != 0 {
+			t.Errorf("%s() called from the host has call site line %d", frame.Function, frame.Site.Line)
+		}
+	}
+
+	_, err = vm.Call("clock")
+	if !errors.As(err, &loxErr) {
+		t.Fatalf("got %v, want an *Error", err)
+	}
+	if line := loxErr.Diagnostics.Items[0].Span.Line; line != 0 {
+		t.Errorf("clock() called from the host reported line %d", line)
+	}
+}
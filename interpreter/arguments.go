@@ -0,0 +1,59 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/rt"
+	"math"
+	"strconv"
+)
+
+// Helpers for natives that check their own arguments. Positions in error
+// messages count from one, as in the binder.
+
+// argumentCount checks the number of arguments given to a native with
+// arity -1. A max of -1 allows any number from min up.
+func (i *Interpreter) argumentCount(arguments []object.Object, min int, max int) {
+	if len(arguments) >= min && (max < 0 || len(arguments) <= max) {
+		return
+	}
+	expected := strconv.Itoa(min)
+	if max < 0 {
+		expected = "at least " + expected
+	} else if max > min {
+		expected += " to " + strconv.Itoa(max)
+	}
+	panic(i.nativeError(rt.CodeArity, "Expected "+expected+" arguments but got "+strconv.Itoa(len(arguments))+"."))
+}
+
+func (i *Interpreter) argumentError(n int, expected string, got object.Object) {
+	panic(i.nativeError(rt.CodeArgumentType, "Argument "+strconv.Itoa(n+1)+": expected "+expected+" but got "+typeName(got)+"."))
+}
+
+func (i *Interpreter) stringArgument(arguments []object.Object, n int) string {
+	s, ok := arguments[n].(object.String)
+	if !ok {
+		i.argumentError(n, "string", arguments[n])
+	}
+	return string(s)
+}
+
+func (i *Interpreter) numberArgument(arguments []object.Object, n int) float64 {
+	number, ok := arguments[n].(object.Number)
+	if !ok {
+		i.argumentError(n, "number", arguments[n])
+	}
+	return float64(number)
+}
+
+func (i *Interpreter) integerArgument(arguments []object.Object, n int) int {
+	number, ok := arguments[n].(object.Number)
+	if !ok || float64(number) != math.Trunc(float64(number)) {
+		i.argumentError(n, "integer", arguments[n])
+	}
+	// Converting a float outside int's range is implementation-defined, so
+	// check the bounds before converting.
+	if float64(number) < math.MinInt || float64(number) >= -math.MinInt {
+		i.argumentError(n, "integer in range", arguments[n])
+	}
+	return int(number)
+}
@@ -0,0 +1,433 @@
+package interpreter
+
+import (
+	"fmt"
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Bind converts a Go value to a Lox value. Functions become natives whose
+// arguments and results are converted automatically, and struct pointers
+// become objects whose exported fields and methods are Lox properties.
+func Bind(value any) (object.Object, error) {
+	return FromGo(reflect.ValueOf(value))
+}
+
+// FromGo converts a Go value to the equivalent Lox value.
+func FromGo(value reflect.Value) (object.Object, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if value.Type().Implements(objectType) && !isNil(value) {
+		return value.Interface().(object.Object), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return object.Boolean(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return object.Number(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return object.Number(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return object.Number(value.Float()), nil
+	case reflect.String:
+		return object.String(value.String()), nil
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil, nil
+		}
+		elements := make([]object.Object, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			element, err := FromGo(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		return NewLoxList(elements), nil
+	case reflect.Map:
+		if value.IsNil() {
+			return nil, nil
+		}
+		keys := value.MapKeys()
+		sort.Slice(keys, func(a, b int) bool {
+			return fmt.Sprint(keys[a].Interface()) < fmt.Sprint(keys[b].Interface())
+		})
+		m := NewLoxMap()
+		for _, key := range keys {
+			k, err := FromGo(key)
+			if err != nil {
+				return nil, err
+			}
+			v, err := FromGo(value.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			m.Put(k, v)
+		}
+		return m, nil
+	case reflect.Func:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return bindFunction(functionName(value), value)
+	case reflect.Interface:
+		return FromGo(value.Elem())
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil, nil
+		}
+		if value.Elem().Kind() == reflect.Struct {
+			return &GoObject{value: value}, nil
+		}
+		return FromGo(value.Elem())
+	case reflect.Struct:
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		return &GoObject{value: pointer}, nil
+	}
+
+	return nil, fmt.Errorf("can't convert Go value of type %s", value.Type())
+}
+
+// ToGo converts a Lox value to a Go value of the given type.
+func ToGo(value object.Object, typ reflect.Type) (reflect.Value, error) {
+	if value != nil && reflect.TypeOf(value).AssignableTo(typ) {
+		return reflect.ValueOf(value), nil
+	}
+	if typ.Kind() == reflect.Interface && typ.NumMethod() == 0 {
+		if value == nil {
+			return reflect.Zero(typ), nil
+		}
+		return reflect.ValueOf(toNative(value)), nil
+	}
+
+	mismatch := func(expected string) (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("expected %s but got %s", expected, typeName(value))
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		if b, ok := value.(object.Boolean); ok {
+			return reflect.ValueOf(bool(b)).Convert(typ), nil
+		}
+		return mismatch("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(object.Number)
+		if !ok || float64(n) != math.Trunc(float64(n)) {
+			return mismatch("integer")
+		}
+		// Compare in float64 first: converting an out-of-range float to
+		// an integer is implementation-defined.
+		limit := math.Ldexp(1, typ.Bits()-1)
+		if float64(n) < -limit || float64(n) >= limit {
+			return reflect.Value{}, fmt.Errorf("%s is out of range for %s", n.ToString(), typ)
+		}
+		result := reflect.New(typ).Elem()
+		result.SetInt(int64(n))
+		return result, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := value.(object.Number)
+		if !ok || float64(n) != math.Trunc(float64(n)) || n < 0 {
+			return mismatch("non-negative integer")
+		}
+		if float64(n) >= math.Ldexp(1, typ.Bits()) {
+			return reflect.Value{}, fmt.Errorf("%s is out of range for %s", n.ToString(), typ)
+		}
+		result := reflect.New(typ).Elem()
+		result.SetUint(uint64(n))
+		return result, nil
+	case reflect.Float32, reflect.Float64:
+		if n, ok := value.(object.Number); ok {
+			return reflect.ValueOf(float64(n)).Convert(typ), nil
+		}
+		return mismatch("number")
+	case reflect.String:
+		if s, ok := value.(object.String); ok {
+			return reflect.ValueOf(string(s)).Convert(typ), nil
+		}
+		return mismatch("string")
+	case reflect.Slice:
+		if value == nil {
+			return reflect.Zero(typ), nil
+		}
+		list, ok := value.(*LoxList)
+		if !ok {
+			return mismatch("list")
+		}
+		result := reflect.MakeSlice(typ, len(list.Elements), len(list.Elements))
+		for i, element := range list.Elements {
+			converted, err := ToGo(element, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			result.Index(i).Set(converted)
+		}
+		return result, nil
+	case reflect.Map:
+		if value == nil {
+			return reflect.Zero(typ), nil
+		}
+		m, ok := value.(*LoxMap)
+		if !ok {
+			return mismatch("map")
+		}
+		result := reflect.MakeMapWithSize(typ, m.Len())
+		for _, key := range m.Keys() {
+			k, err := ToGo(key, typ.Key())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %s: %w", quote(key), err)
+			}
+			entry, _ := m.Lookup(key)
+			v, err := ToGo(entry, typ.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("entry %s: %w", quote(key), err)
+			}
+			result.SetMapIndex(k, v)
+		}
+		return result, nil
+	case reflect.Pointer, reflect.Interface:
+		if value == nil {
+			return reflect.Zero(typ), nil
+		}
+		if o, ok := value.(*GoObject); ok && o.value.Type().AssignableTo(typ) {
+			return o.value, nil
+		}
+	case reflect.Struct:
+		if o, ok := value.(*GoObject); ok && o.value.Elem().Type() == typ {
+			return o.value.Elem(), nil
+		}
+	}
+
+	return mismatch(typ.String())
+}
+
+// toNative picks the natural Go representation of a Lox value, for
+// parameters declared as any.
+func toNative(value object.Object) any {
+	switch v := value.(type) {
+	case object.Number:
+		return float64(v)
+	case object.String:
+		return string(v)
+	case object.Boolean:
+		return bool(v)
+	case *LoxList:
+		elements := make([]any, 0, len(v.Elements))
+		for _, element := range v.Elements {
+			elements = append(elements, toNative(element))
+		}
+		return elements
+	case *LoxMap:
+		entries := make(map[string]any, v.Len())
+		for _, key := range v.Keys() {
+			entry, _ := v.Lookup(key)
+			entries[stringify(key)] = toNative(entry)
+		}
+		return entries
+	case *GoObject:
+		return v.value.Interface()
+	}
+	return value
+}
+
+// BindFunction wraps a Go function as a native. A trailing error result is
+// raised as a runtime error when it is not nil.
+func BindFunction(function any) (*Native, error) {
+	value := reflect.ValueOf(function)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return nil, fmt.Errorf("can't bind %T as a function", function)
+	}
+	return bindFunction(functionName(value), value)
+}
+
+// functionName finds the Go name of a function for stack traces.
+func functionName(function reflect.Value) string {
+	name := "native"
+	if f := runtime.FuncForPC(function.Pointer()); f != nil {
+		name = f.Name()
+		name = name[strings.LastIndexByte(name, '.')+1:]
+	}
+	return name
+}
+
+func bindFunction(name string, function reflect.Value) (*Native, error) {
+	typ := function.Type()
+	results := typ.NumOut()
+	if results > 2 || results == 2 && typ.Out(1) != errorType {
+		return nil, fmt.Errorf("can't bind %s: functions may return a value and an error", typ)
+	}
+
+	required := typ.NumIn()
+	arity := required
+	if typ.IsVariadic() {
+		required--
+		arity = -1
+	}
+
+	return NewNative(name, arity, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		if typ.IsVariadic() && len(arguments) < required {
+			panic(interpreter.nativeError(rt.CodeArity, "Expected at least "+strconv.Itoa(required)+
+				" arguments but got "+strconv.Itoa(len(arguments))+"."))
+		}
+
+		in := make([]reflect.Value, 0, len(arguments))
+		for i, argument := range arguments {
+			var parameter reflect.Type
+			if typ.IsVariadic() && i >= required {
+				parameter = typ.In(required).Elem()
+			} else {
+				parameter = typ.In(i)
+			}
+
+			converted, err := ToGo(argument, parameter)
+			if err != nil {
+				panic(interpreter.nativeError(rt.CodeArgumentType, "Argument "+strconv.Itoa(i+1)+": "+err.Error()+"."))
+			}
+			in = append(in, converted)
+		}
+
+		out := interpreter.callGo(function, in)
+		if len(out) > 0 && typ.Out(len(out)-1) == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				panic(rt.RuntimeError{Token: interpreter.callSite, Code: rt.CodeHost, Message: err.Error(), Cause: err})
+			}
+			out = out[:len(out)-1]
+		}
+		if len(out) == 0 {
+			return nil
+		}
+
+		result, err := FromGo(out[0])
+		if err != nil {
+			panic(interpreter.nativeError(rt.CodeConversion, "Can't convert result: "+err.Error()+"."))
+		}
+		return result
+	}), nil
+}
+
+// callGo calls a bound Go function, turning a panic inside it into a
+// runtime error so that it can't escape to the host.
+func (i *Interpreter) callGo(function reflect.Value, in []reflect.Value) []reflect.Value {
+	defer func() {
+		if err := recover(); err != nil {
+			if _, ok := err.(rt.RuntimeError); ok {
+				panic(err)
+			}
+			cause, ok := err.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", err)
+			}
+			panic(rt.RuntimeError{Token: i.callSite, Code: rt.CodeHost, Message: "Go function panicked: " + cause.Error() + ".",
+				Cause: cause})
+		}
+	}()
+	return function.Call(in)
+}
+
+// GoObject exposes a pointer to a Go struct to Lox. Exported fields and
+// methods are properties, matched either exactly or with the first letter
+// lowercased; a `lox:"name"` tag renames a field.
+type GoObject struct {
+	value reflect.Value
+}
+
+// Value returns the Go pointer the object wraps.
+func (o *GoObject) Value() any {
+	return o.value.Interface()
+}
+
+func (o *GoObject) Get(name token.Token) object.Object {
+	if field, ok := o.field(name.Lexeme); ok {
+		value, err := FromGo(field)
+		if err != nil {
+			panic(rt.RuntimeError{Token: name, Code: rt.CodeConversion, Message: "Can't read '" + name.Lexeme + "': " + err.Error() + "."})
+		}
+		return value
+	}
+
+	if method, ok := o.method(name.Lexeme); ok {
+		native, err := bindFunction(name.Lexeme, method)
+		if err != nil {
+			panic(rt.RuntimeError{Token: name, Code: rt.CodeConversion, Message: "Can't call '" + name.Lexeme + "': " + err.Error() + "."})
+		}
+		return native
+	}
+
+	panic(undefinedProperty(name))
+}
+
+func (o *GoObject) Set(name token.Token, value object.Object) {
+	field, ok := o.field(name.Lexeme)
+	if !ok {
+		panic(rt.RuntimeError{Token: name, Code: rt.CodeUndefinedProperty, Message: "Undefined field '" + name.Lexeme + "'."})
+	}
+
+	converted, err := ToGo(value, field.Type())
+	if err != nil {
+		panic(rt.RuntimeError{Token: name, Code: rt.CodeConversion, Message: "Can't assign to '" + name.Lexeme + "': " + err.Error() + "."})
+	}
+	field.Set(converted)
+}
+
+func (o *GoObject) field(name string) (reflect.Value, bool) {
+	structValue := o.value.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("lox")
+		if tag == name || tag == "" && (field.Name == name || lowerFirst(field.Name) == name) {
+			return structValue.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (o *GoObject) method(name string) (reflect.Value, bool) {
+	method := o.value.MethodByName(name)
+	if !method.IsValid() {
+		method = o.value.MethodByName(upperFirst(name))
+	}
+	return method, method.IsValid()
+}
+
+func (o *GoObject) ToString() string {
+	if stringer, ok := o.value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return "<go " + o.value.Elem().Type().String() + ">"
+}
+
+func isNil(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return value.IsNil()
+	}
+	return false
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
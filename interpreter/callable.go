@@ -2,9 +2,29 @@ package interpreter
 
 import (
 	"golox/object"
+	"golox/rt"
+	"golox/token"
 )
 
 type LoxCallable interface {
+	// Arity returns the number of arguments expected, or -1 if the callable
+	// checks its own arguments.
 	Arity() int
 	Call(interpreter *Interpreter, arguments []object.Object) object.Object
 }
+
+// propertyGetter is implemented by values that support "." access.
+type propertyGetter interface {
+	Get(name token.Token) object.Object
+}
+
+// propertySetter is implemented by values whose properties can be assigned.
+type propertySetter interface {
+	Set(name token.Token, value object.Object)
+}
+
+// undefinedProperty is the error a propertyGetter raises for an unknown name.
+func undefinedProperty(name token.Token) rt.RuntimeError {
+	return rt.RuntimeError{Token: name, Code: rt.CodeUndefinedProperty,
+		Message: "Undefined property '" + name.Lexeme + "'."}
+}
@@ -0,0 +1,124 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/rt"
+	"path/filepath"
+	"strings"
+)
+
+// Capability names a permission that natives touching the host require.
+type Capability string
+
+const (
+	FSRead  Capability = "fs-read"
+	FSWrite Capability = "fs-write"
+	Env     Capability = "env"
+	Clock   Capability = "clock"
+	Exec    Capability = "exec"
+)
+
+// Capabilities is the set of permissions granted to an interpreter. The
+// zero value grants nothing.
+type Capabilities struct {
+	granted map[Capability][]string
+}
+
+// AllCapabilities grants every capability without path restrictions, as
+// the command line does.
+func AllCapabilities() *Capabilities {
+	return new(Capabilities).Grant(FSRead).Grant(FSWrite).Grant(Env).Grant(Clock).Grant(Exec)
+}
+
+// Grant adds capability to the set. For the file system capabilities,
+// paths restrict access to those files and directories; with no paths any
+// path is allowed. Grant returns c so calls can be chained.
+func (c *Capabilities) Grant(capability Capability, paths ...string) *Capabilities {
+	if c.granted == nil {
+		c.granted = make(map[Capability][]string)
+	}
+
+	roots := c.granted[capability]
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			roots = append(roots, abs)
+		}
+	}
+	c.granted[capability] = roots
+	return c
+}
+
+func (c *Capabilities) Allows(capability Capability) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.granted[capability]
+	return ok
+}
+
+// AllowsPath reports whether capability is granted for path, honouring any
+// path allowlist it was granted with.
+func (c *Capabilities) AllowsPath(capability Capability, path string) bool {
+	if !c.Allows(capability) {
+		return false
+	}
+
+	roots := c.granted[capability]
+	if len(roots) == 0 {
+		return true
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+	for _, root := range roots {
+		// Roots are resolved on each check, since they may be created or
+		// relinked after the grant.
+		if real, err := resolvePath(root); err == nil {
+			root = real
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath makes path absolute and follows any symbolic links in it, so
+// that a link inside an allowed directory can't reach a file outside it.
+// A path that doesn't exist yet, such as a file about to be written, is
+// resolved through its nearest existing parent.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rest := ""
+	for {
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			return filepath.Join(real, rest), nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return filepath.Join(abs, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(abs), rest)
+		abs = parent
+	}
+}
+
+// require stops the run unless capability has been granted.
+func (i *Interpreter) require(capability Capability) {
+	if !i.Capabilities.Allows(capability) {
+		panic(i.nativeError(rt.CodeMissingCapability, "Missing capability '"+string(capability)+"'."))
+	}
+}
+
+// requirePath stops the run unless capability has been granted for path.
+func (i *Interpreter) requirePath(capability Capability, path object.String) {
+	i.require(capability)
+	if !i.Capabilities.AllowsPath(capability, string(path)) {
+		panic(i.nativeError(rt.CodeMissingCapability, "Missing capability '"+string(capability)+"' for path '"+string(path)+"'."))
+	}
+}
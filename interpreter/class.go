@@ -2,12 +2,14 @@ package interpreter
 
 import (
 	"golox/object"
+	"golox/stmt"
 )
 
 type LoxClass struct {
-	Name       string
-	Superclass *LoxClass
-	Methods    map[string]*LoxFunction
+	Name        string
+	Superclass  *LoxClass
+	Methods     map[string]*LoxFunction
+	Declaration *stmt.Class
 }
 
 func NewLoxClass(Name string, Superclass *LoxClass, Methods map[string]*LoxFunction) *LoxClass {
@@ -35,11 +37,14 @@ func (c *LoxClass) ToString() string {
 }
 
 func (c *LoxClass) Call(interpreter *Interpreter, arguments []object.Object) object.Object {
+	interpreter.enter(c.Name)
+	interpreter.charge(instanceSize, &interpreter.stats.Instances, interpreter.callSite)
 	instance := NewLoxInstance(c)
 	initializer := c.FindMethod("init")
 	if initializer != nil {
 		initializer.Bind(instance).Call(interpreter, arguments)
 	}
+	interpreter.leave()
 	return instance
 }
 
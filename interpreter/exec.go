@@ -0,0 +1,125 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"golox/object"
+	rt2 "golox/rt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execCommand runs a program for os.exec, which takes the command, an
+// optional list of arguments and an optional map of options: stdin (a
+// string fed to the program), cwd, env (a map of variables added to the
+// current environment) and timeout in milliseconds. It returns a map with
+// the program's stdout, stderr and exit code. The run's deadline and
+// context also stop the program.
+func (i *Interpreter) execCommand(arguments []object.Object) object.Object {
+	i.argumentCount(arguments, 1, 3)
+	name := i.stringArgument(arguments, 0)
+
+	var args []string
+	if len(arguments) >= 2 && arguments[1] != nil {
+		list, ok := arguments[1].(*LoxList)
+		if !ok {
+			i.argumentError(1, "list", arguments[1])
+		}
+		for _, element := range list.Elements {
+			args = append(args, i.execString(element, "Command arguments"))
+		}
+	}
+
+	var options *LoxMap
+	if len(arguments) == 3 && arguments[2] != nil {
+		var ok bool
+		if options, ok = arguments[2].(*LoxMap); !ok {
+			i.argumentError(2, "map", arguments[2])
+		}
+	} else {
+		options = NewLoxMap()
+	}
+
+	ctx := i.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !i.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, i.Deadline)
+		defer cancel()
+	}
+	run := ctx
+	if timeout, ok := options.Lookup(object.String("timeout")); ok && timeout != nil {
+		n, ok := timeout.(object.Number)
+		limit, inRange := duration(float64(n))
+		if !ok || !inRange || limit <= 0 {
+			panic(i.NativeError("Option 'timeout' must be a positive number of milliseconds within range."))
+		}
+		var cancel context.CancelFunc
+		run, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+
+	command := exec.CommandContext(run, name, args...)
+	if stdin, ok := options.Lookup(object.String("stdin")); ok && stdin != nil {
+		command.Stdin = strings.NewReader(i.execString(stdin, "Option 'stdin'"))
+	}
+	if cwd, ok := options.Lookup(object.String("cwd")); ok && cwd != nil {
+		command.Dir = i.execString(cwd, "Option 'cwd'")
+	}
+	if env, ok := options.Lookup(object.String("env")); ok && env != nil {
+		variables, ok := env.(*LoxMap)
+		if !ok {
+			panic(i.NativeError("Option 'env' must be a map."))
+		}
+		command.Env = os.Environ()
+		for _, key := range variables.Keys() {
+			value, _ := variables.Lookup(key)
+			command.Env = append(command.Env,
+				i.execString(key, "Option 'env'")+"="+i.execString(value, "Option 'env'"))
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	err := command.Run()
+
+	if err := ctx.Err(); err != nil {
+		if !i.Deadline.IsZero() && !time.Now().Before(i.Deadline) {
+			panic(stop(i.callSite, rt2.CodeDeadline, "deadline exceeded", rt2.ErrDeadline))
+		}
+		panic(stop(i.callSite, rt2.CodeCancelled, err.Error(), err))
+	}
+	if run.Err() != nil {
+		panic(i.NativeError("Command '" + name + "' timed out."))
+	}
+
+	code := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		panic(i.hostError(err))
+	}
+
+	i.chargeCollection(mapSize + 3*entrySize)
+	result := NewLoxMap()
+	result.Put(object.String("stdout"), i.newString(stdout.String()))
+	result.Put(object.String("stderr"), i.newString(stderr.String()))
+	result.Put(object.String("code"), object.Number(code))
+	return result
+}
+
+// execString checks that a value given to os.exec is a string.
+func (i *Interpreter) execString(value object.Object, what string) string {
+	s, ok := value.(object.String)
+	if !ok {
+		panic(i.NativeError(what + " must be strings, not " + typeName(value) + "."))
+	}
+	return string(s)
+}
@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"bufio"
+	"errors"
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+	"os"
+)
+
+// fsModule builds the fs namespace. Reading needs the fs-read capability
+// and changing the disk fs-write, both checked against the path. Failures
+// reported by the operating system become runtime errors wrapping the Go
+// error.
+func fsModule() *LoxModule {
+	module := NewLoxModule("fs")
+	module.Function("readFile", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSRead)
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return interpreter.newString(string(bytes))
+	})
+	module.Function("writeFile", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSWrite)
+		if err := os.WriteFile(path, []byte(interpreter.stringArgument(arguments, 1)), 0o666); err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return nil
+	})
+	module.Function("appendFile", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSWrite)
+		text := interpreter.stringArgument(arguments, 1)
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o666)
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		_, err = file.WriteString(text)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return nil
+	})
+	module.Function("listDir", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSRead)
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return interpreter.stringList(names)
+	})
+	module.Function("exists", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSRead)
+		_, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return object.Boolean(false)
+		} else if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return object.Boolean(true)
+	})
+	module.Function("remove", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSWrite)
+		if err := os.Remove(path); err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return nil
+	})
+	module.Function("mkdir", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSWrite)
+		if err := os.MkdirAll(path, 0o777); err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return nil
+	})
+	module.Function("open", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		path := interpreter.pathArgument(arguments, 0, FSRead)
+		file, err := os.Open(path)
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return &LoxFile{path: path, file: file, reader: bufio.NewReader(file)}
+	})
+	return module
+}
+
+// pathArgument returns argument n as a path the script may access with
+// capability.
+func (i *Interpreter) pathArgument(arguments []object.Object, n int, capability Capability) string {
+	path := i.stringArgument(arguments, n)
+	i.requirePath(capability, object.String(path))
+	return path
+}
+
+// hostError wraps an error from the host in a RuntimeError located at the
+// current call, so embedders can still inspect it with errors.Is. The
+// message is Go's text unchanged, as for errors from bound functions.
+func (i *Interpreter) hostError(err error) rt.RuntimeError {
+	return rt.RuntimeError{Token: i.callSite, Code: rt.CodeHost, Message: err.Error(), Cause: err}
+}
+
+// LoxFile is a file opened for reading line by line with fs.open.
+type LoxFile struct {
+	path   string
+	file   *os.File
+	reader *bufio.Reader
+}
+
+func (f *LoxFile) Get(name token.Token) object.Object {
+	switch name.Lexeme {
+	case "readLine":
+		return NewNative("readLine", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			if f.file == nil {
+				panic(interpreter.NativeError("File is closed."))
+			}
+			return interpreter.readLine(f.reader)
+		})
+	case "close":
+		return NewNative("close", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			if f.file == nil {
+				return nil
+			}
+			err := f.file.Close()
+			f.file = nil
+			if err != nil {
+				panic(interpreter.hostError(err))
+			}
+			return nil
+		})
+	}
+
+	panic(undefinedProperty(name))
+}
+
+func (f *LoxFile) ToString() string {
+	return "<file " + f.path + ">"
+}
@@ -27,25 +27,38 @@ func (f *LoxFunction) Bind(instance *LoxInstance) *LoxFunction {
 	return NewLoxFunction(f.declaration, environment, f.isInitializer)
 }
 
+func (f *LoxFunction) Declaration() *stmt.Function {
+	return f.declaration
+}
+
 func (f *LoxFunction) Arity() int {
 	return len(f.declaration.Params)
 }
 
 func (f *LoxFunction) Call(inter *Interpreter, arguments []object.Object) (ret object.Object) {
+	inter.chargeEnvironment(len(f.declaration.Params), inter.callSite)
 	environment := rt2.NewEnvironment(f.closure)
 	for i := 0; i < len(f.declaration.Params); i++ {
 		environment.Define(f.declaration.Params[i].Lexeme, arguments[i])
 	}
+	defer inter.release(environment)
 
+	// Frames are only popped on a normal return, so that a runtime error
+	// unwinding past this call still sees the full chain.
+	inter.enter(f.declaration.Name.Lexeme)
 	defer func() {
 		if err := recover(); err != nil {
 			if rv, ok := err.(rt2.Return); ok {
+				inter.leave()
 				ret = rv.Value
+			} else {
+				panic(err)
 			}
 		}
 	}()
 
 	inter.ExecuteBlock(f.declaration.Body, environment)
+	inter.leave()
 
 	if f.isInitializer {
 		return f.closure.GetAt(0, "this")
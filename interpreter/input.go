@@ -0,0 +1,30 @@
+package interpreter
+
+import (
+	"bufio"
+	"golox/object"
+	"io"
+	"strings"
+)
+
+// input returns a buffered reader over Stdin, created on first use and
+// again whenever the host replaces Stdin.
+func (i *Interpreter) input() *bufio.Reader {
+	if i.stdin == nil || i.stdinSource != i.Stdin {
+		i.stdin = bufio.NewReader(i.Stdin)
+		i.stdinSource = i.Stdin
+	}
+	return i.stdin
+}
+
+// readLine reads the next line from reader without its line ending, or
+// nil at the end of input.
+func (i *Interpreter) readLine(reader *bufio.Reader) object.Object {
+	line, err := reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil
+	} else if err != nil && err != io.EOF {
+		panic(i.hostError(err))
+	}
+	return i.newString(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+}
@@ -2,7 +2,6 @@ package interpreter
 
 import (
 	"golox/object"
-	"golox/rt"
 	"golox/token"
 )
 
@@ -15,6 +14,14 @@ func NewLoxInstance(class *LoxClass) *LoxInstance {
 	return &LoxInstance{class: class, fields: make(map[string]object.Object)}
 }
 
+func (i *LoxInstance) Class() *LoxClass {
+	return i.class
+}
+
+func (i *LoxInstance) Fields() map[string]object.Object {
+	return i.fields
+}
+
 func (i *LoxInstance) Get(name token.Token) object.Object {
 	if object, ok := i.fields[name.Lexeme]; ok {
 		return object
@@ -25,7 +32,7 @@ func (i *LoxInstance) Get(name token.Token) object.Object {
 		return method.Bind(i)
 	}
 
-	panic(rt.RuntimeError{Token: name, Message: "Undefined property '" + name.Lexeme + "'."})
+	panic(undefinedProperty(name))
 }
 
 func (i *LoxInstance) Set(name token.Token, value object.Object) {
@@ -1,47 +1,286 @@
 package interpreter
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"golox/expr"
 	. "golox/object"
 	rt2 "golox/rt"
 	"golox/stmt"
 	"golox/token"
+	"io"
+	"math/rand/v2"
+	"os"
 	"strconv"
-	"strings"
 	"time"
 )
 
+// DefaultMaxCallDepth keeps recursion well inside the Go stack.
+const DefaultMaxCallDepth = 10000
+
 type Interpreter struct {
 	Globals     *rt2.Environment
 	Environment *rt2.Environment
 	locals      map[expr.Expr]int
-}
 
+	// Stdout receives the output of print, Stderr error reports, and
+	// Stdin feeds input natives. They default to the process streams.
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// stdin buffers stdinSource, the Stdin it was created for.
+	stdin       *bufio.Reader
+	stdinSource io.Reader
+
+	// MaxSteps bounds the loop iterations and calls a run may make; zero
+	// means no limit. A run also stops once Deadline passes or Context is
+	// cancelled.
+	MaxSteps uint64
+	Deadline time.Time
+	Context  context.Context
+	steps    uint64
+
+	// MaxCallDepth bounds how deeply Lox functions may call each other
+	// before the run stops with a stack overflow.
+	MaxCallDepth int
+	frames       []rt2.Frame
+
+	// MaxMemory bounds the approximate bytes a run may allocate for
+	// strings, instances, environments and collections, less the
+	// environments of blocks and calls that have returned; zero means no
+	// limit.
+	MaxMemory uint64
+	stats     Stats
+	live      uint64
+
+	// Capabilities lists what natives touching the host may do. Nil
+	// grants nothing.
+	Capabilities *Capabilities
+
+	// random backs math.random; math.seed replaces it.
+	random *rand.Rand
+
+	// os is the os module, whose args SetArgs replaces. exit holds the
+	// status passed to exit until Exited reads it.
+	os   *LoxModule
+	exit *exit
+
+	// callSite is the closing parenthesis of the innermost call, used to
+	// locate errors raised by natives.
+	callSite token.Token
+}
+
+// NewInterpreter creates an interpreter with its own globals, resolution
+// table, limits and statistics. Interpreters share no Lox state, so
+// separate ones may run on separate goroutines; a single interpreter must
+// not be used from two goroutines at once. The host process is shared,
+// though: os.setEnv changes the environment every interpreter sees, and
+// os.cwd and relative fs paths depend on the process working directory.
 func NewInterpreter() *Interpreter {
 	globals := rt2.NewEnvironment(nil)
-	globals.Define("clock", NewNative(0, func(interpreter *Interpreter, arguments []Object) Object {
+	globals.Define("clock", NewNative("clock", 0, func(interpreter *Interpreter, arguments []Object) Object {
 		return Number(time.Now().UnixNano() / int64(time.Millisecond))
+	}).Requires(Clock))
+	globals.Define("List", NewNative("List", 0, func(interpreter *Interpreter, arguments []Object) Object {
+		interpreter.chargeCollection(listSize)
+		return NewLoxList(make([]Object, 0))
+	}))
+	globals.Define("Map", NewNative("Map", 0, func(interpreter *Interpreter, arguments []Object) Object {
+		interpreter.chargeCollection(mapSize)
+		return NewLoxMap()
+	}))
+	globals.Define("write", NewNative("write", 1, func(interpreter *Interpreter, arguments []Object) Object {
+		_, _ = io.WriteString(interpreter.Stdout, interpreter.display(arguments[0], interpreter.callSite))
+		return nil
+	}))
+	globals.Define("printf", NewNative("printf", -1, func(interpreter *Interpreter, arguments []Object) Object {
+		interpreter.argumentCount(arguments, 1, -1)
+		text := interpreter.sprintf(interpreter.stringArgument(arguments, 0), arguments[1:])
+		_, _ = io.WriteString(interpreter.Stdout, text)
+		return nil
+	}))
+	globals.Define("sprintf", NewNative("sprintf", -1, func(interpreter *Interpreter, arguments []Object) Object {
+		interpreter.argumentCount(arguments, 1, -1)
+		return interpreter.newString(interpreter.sprintf(interpreter.stringArgument(arguments, 0), arguments[1:]))
+	}))
+	globals.Define("input", NewNative("input", -1, func(interpreter *Interpreter, arguments []Object) Object {
+		interpreter.argumentCount(arguments, 0, 1)
+		if len(arguments) == 1 {
+			_, _ = io.WriteString(interpreter.Stdout, interpreter.display(arguments[0], interpreter.callSite))
+			interpreter.flush()
+		}
+		return interpreter.readLine(interpreter.input())
+	}))
+	globals.Define("readLine", NewNative("readLine", 0, func(interpreter *Interpreter, arguments []Object) Object {
+		return interpreter.readLine(interpreter.input())
+	}))
+	globals.Define("readAll", NewNative("readAll", 0, func(interpreter *Interpreter, arguments []Object) Object {
+		bytes, err := io.ReadAll(interpreter.input())
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return interpreter.newString(string(bytes))
+	}))
+	globals.Define("math", mathModule())
+	globals.Define("fs", fsModule())
+	globals.Define("json", jsonModule())
+	globals.Define("re", reModule())
+	globals.Define("time", timeModule())
+	module := osModule()
+	globals.Define("os", module)
+	globals.Define("exit", NewNative("exit", -1, func(interpreter *Interpreter, arguments []Object) Object {
+		interpreter.argumentCount(arguments, 0, 1)
+		code := 0
+		if len(arguments) == 1 {
+			code = interpreter.integerArgument(arguments, 0)
+		}
+		interpreter.flush()
+		panic(exit{code: code})
 	}))
 	return &Interpreter{
 		Globals:     globals,
 		Environment: globals,
 		locals:      make(map[expr.Expr]int),
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		Stdin:       os.Stdin,
+
+		MaxCallDepth: DefaultMaxCallDepth,
+		random:       newRandom(rand.Uint64()),
+		os:           module,
 	}
 }
 
-func (i *Interpreter) Interpret(statements []stmt.Stmt) {
-	defer func() {
-		if err := recover(); err != nil {
-			if e, ok := err.(rt2.RuntimeError); ok {
-				rt2.ErrorRuntime(e)
-			}
-		}
-	}()
+func (i *Interpreter) Interpret(statements []stmt.Stmt) (diagnostics *rt2.Diagnostics) {
+	diagnostics = rt2.NewDiagnostics()
+	defer i.catch(diagnostics, len(i.frames))
 
 	for _, statement := range statements {
 		i.execute(statement)
 	}
+	return diagnostics
+}
+
+// Evaluate runs a single expression and returns its value.
+func (i *Interpreter) Evaluate(expression expr.Expr) (value Object, diagnostics *rt2.Diagnostics) {
+	diagnostics = rt2.NewDiagnostics()
+	defer i.catch(diagnostics, len(i.frames))
+
+	return i.evaluate(expression), diagnostics
+}
+
+// CallFunction invokes a Lox callable from Go with already evaluated
+// arguments.
+func (i *Interpreter) CallFunction(function LoxCallable, arguments []Object) (value Object,
+	diagnostics *rt2.Diagnostics) {
+	diagnostics = rt2.NewDiagnostics()
+	defer i.catch(diagnostics, len(i.frames))
+	// The host made this call, so there is no call site in the source.
+	defer i.restoreCallSite(i.callSite)
+	i.callSite = token.Token{}
+
+	if function.Arity() >= 0 && len(arguments) != function.Arity() {
+		panic(rt2.RuntimeError{Code: rt2.CodeArity, Message: "Expected " + strconv.Itoa(function.Arity()) +
+			" arguments but got " + strconv.Itoa(len(arguments)) + "."})
+	}
+	return function.Call(i, arguments), diagnostics
+}
+
+// restoreCallSite puts back the call site of the enclosing call once a
+// call returns, or unwinds with an error.
+func (i *Interpreter) restoreCallSite(previous token.Token) {
+	i.callSite = previous
+}
+
+// ResetSteps starts a fresh step budget for the next run.
+func (i *Interpreter) ResetSteps() {
+	i.steps = 0
+}
+
+// step counts one unit of work against the execution limits, stopping the
+// run at the given token once any of them is exceeded. The clock and the
+// context are only consulted every few steps to keep loops cheap.
+func (i *Interpreter) step(at token.Token) {
+	i.steps++
+	if i.MaxSteps > 0 && i.steps > i.MaxSteps {
+		panic(stop(at, rt2.CodeStepLimit, "step limit exceeded", rt2.ErrStepLimit))
+	}
+	if i.steps%64 != 0 {
+		return
+	}
+	if !i.Deadline.IsZero() && time.Now().After(i.Deadline) {
+		panic(stop(at, rt2.CodeDeadline, "deadline exceeded", rt2.ErrDeadline))
+	}
+	if i.Context != nil {
+		if err := i.Context.Err(); err != nil {
+			panic(stop(at, rt2.CodeCancelled, err.Error(), err))
+		}
+	}
+}
+
+// stop builds the error that ends a run cut short by cause.
+func stop(at token.Token, code rt2.Code, reason string, cause error) rt2.RuntimeError {
+	return rt2.RuntimeError{Token: at, Code: code, Message: "Execution stopped: " + reason + ".", Cause: cause}
+}
+
+// enter pushes a call frame for function, raising a stack overflow when
+// that would exceed MaxCallDepth. The frame is popped by leave when the
+// call returns normally; after an error, catch unwinds it.
+func (i *Interpreter) enter(function string) {
+	if i.MaxCallDepth > 0 && len(i.frames) >= i.MaxCallDepth {
+		panic(rt2.RuntimeError{Token: i.callSite, Code: rt2.CodeStackOverflow, Message: "Stack overflow."})
+	}
+	i.frames = append(i.frames, rt2.Frame{Function: function, Site: i.callSite})
+}
+
+func (i *Interpreter) leave() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// trace copies the active call frames, innermost first.
+func (i *Interpreter) trace() []rt2.Frame {
+	trace := make([]rt2.Frame, 0, len(i.frames))
+	for j := len(i.frames) - 1; j >= 0; j-- {
+		trace = append(trace, i.frames[j])
+	}
+	return trace
+}
+
+// NativeError builds a RuntimeError located at the call currently being
+// made, for natives to panic with.
+func (i *Interpreter) NativeError(message string) rt2.RuntimeError {
+	return i.nativeError(rt2.CodeNative, message)
+}
+
+// nativeError is NativeError with a more specific code.
+func (i *Interpreter) nativeError(code rt2.Code, message string) rt2.RuntimeError {
+	return rt2.RuntimeError{Token: i.callSite, Code: code, Message: message}
+}
+
+// catch records a RuntimeError raised while running the program, along
+// with the call frames still active when it was raised, or the status
+// passed to exit, and drops those frames back to depth. It must be
+// deferred directly so that recover sees the panic.
+func (i *Interpreter) catch(diagnostics *rt2.Diagnostics, depth int) {
+	if err := recover(); err != nil {
+		if e, ok := err.(exit); ok {
+			i.frames = i.frames[:depth]
+			i.exit = &e
+			return
+		}
+		e, ok := err.(rt2.RuntimeError)
+		if !ok {
+			i.frames = i.frames[:depth]
+			panic(err)
+		}
+		if e.Trace == nil {
+			e.Trace = i.trace()
+		}
+		i.frames = i.frames[:depth]
+		diagnostics.ErrorRuntime(e)
+	}
 }
 
 func (i *Interpreter) execute(statement stmt.Stmt) {
@@ -71,7 +310,10 @@ func (i *Interpreter) ExecuteBlock(statements []stmt.Stmt, environment *rt2.Envi
 }
 
 func (i *Interpreter) VisitBlockStmt(stmt *stmt.Block) Object {
-	i.ExecuteBlock(stmt.Statements, rt2.NewEnvironment(i.Environment))
+	i.chargeEnvironment(0, token.Token{Span: stmt.Range})
+	environment := rt2.NewEnvironment(i.Environment)
+	defer i.release(environment)
+	i.ExecuteBlock(stmt.Statements, environment)
 	return nil
 }
 
@@ -80,7 +322,8 @@ func (i *Interpreter) VisitClassStmt(stmt *stmt.Class) Object {
 	if stmt.Superclass != nil {
 		superclass = i.evaluate(stmt.Superclass)
 		if _, ok := superclass.(*LoxClass); !ok {
-			panic(rt2.RuntimeError{Token: stmt.Superclass.Name, Message: "Superclass must be a class."})
+			panic(rt2.RuntimeError{Token: stmt.Superclass.Name, Code: rt2.CodeInvalidSuperclass,
+				Message: "Superclass must be a class."})
 		}
 	}
 
@@ -104,6 +347,8 @@ func (i *Interpreter) VisitClassStmt(stmt *stmt.Class) Object {
 		class = NewLoxClass(stmt.Name.Lexeme, nil, methods)
 	}
 
+	class.Declaration = stmt
+
 	if superclass != nil {
 		i.Environment = i.Environment.Enclosing
 	}
@@ -135,7 +380,7 @@ func (i *Interpreter) VisitIfStmt(stmt *stmt.If) Object {
 
 func (i *Interpreter) VisitPrintStmt(stmt *stmt.Print) Object {
 	value := i.evaluate(stmt.Expression)
-	fmt.Println(stringify(value))
+	_, _ = fmt.Fprintln(i.Stdout, i.display(value, token.Token{Span: stmt.Range}))
 
 	return nil
 }
@@ -154,12 +399,14 @@ func (i *Interpreter) VisitVarStmt(stmt *stmt.Var) Object {
 		value = i.evaluate(stmt.Initializer)
 	}
 
+	i.charge(variableSize, nil, stmt.Name)
 	i.Environment.Define(stmt.Name.Lexeme, value)
 	return nil
 }
 
 func (i *Interpreter) VisitWhileStmt(stmt *stmt.While) Object {
 	for isTruthy(i.evaluate(stmt.Condition)) {
+		i.step(stmt.Keyword)
 		i.execute(stmt.Body)
 	}
 	return nil
@@ -191,7 +438,7 @@ func (i *Interpreter) VisitBinaryExpr(expr *expr.Binary) Object {
 		checkNumberOperands(expr.Operator, left, right)
 		l := left.(Number)
 		r := right.(Number)
-		return Boolean(l > r)
+		return Boolean(l >= r)
 	case token.Less:
 		checkNumberOperands(expr.Operator, left, right)
 		l := left.(Number)
@@ -217,9 +464,11 @@ func (i *Interpreter) VisitBinaryExpr(expr *expr.Binary) Object {
 			l3, ok1 := left.(String)
 			l4, ok2 := right.(String)
 			if ok1 && ok2 {
+				i.chargeString(len(l3)+len(l4), expr.Operator)
 				return l3 + l4
 			}
-			panic(rt2.RuntimeError{expr.Operator, "Operands must be two numbers or two strings."})
+			panic(rt2.RuntimeError{Token: expr.Operator, Code: rt2.CodeOperandType,
+				Message: "Operands must be two numbers or two strings."})
 		}
 	case token.Slash:
 		checkNumberOperands(expr.Operator, left, right)
@@ -250,25 +499,31 @@ func (i *Interpreter) VisitCallExpr(expr *expr.Call) Object {
 
 	function, ok := callee.(LoxCallable)
 	if !ok {
-		panic(rt2.RuntimeError{expr.Paren, "Can only call functions and classes."})
+		panic(rt2.RuntimeError{Token: expr.Paren, Code: rt2.CodeNotCallable, Message: "Can only call functions and classes."})
 	}
 
-	if len(arguments) != function.Arity() {
-		panic(rt2.RuntimeError{Token: expr.Paren,
-			Message: "Expected " + strconv.FormatInt(int64(function.Arity()), 64) +
-				" arguments but got " + strconv.FormatInt(int64(len(arguments)), 64) + "."})
+	if function.Arity() >= 0 && len(arguments) != function.Arity() {
+		panic(rt2.RuntimeError{Token: expr.Paren, Code: rt2.CodeArity,
+			Message: "Expected " + strconv.Itoa(function.Arity()) +
+				" arguments but got " + strconv.Itoa(len(arguments)) + "."})
 	}
 
+	i.step(expr.Paren)
+	defer i.restoreCallSite(i.callSite)
+	i.callSite = expr.Paren
 	return function.Call(i, arguments)
 }
 
 func (i *Interpreter) VisitGetExpr(expr *expr.Get) Object {
 	object := i.evaluate(expr.Object)
-	if o, ok := object.(*LoxInstance); ok {
+	if o, ok := object.(propertyGetter); ok {
 		return o.Get(expr.Name)
 	}
+	if s, ok := object.(String); ok {
+		return i.stringMethod(s, expr.Name)
+	}
 
-	panic(rt2.RuntimeError{Token: expr.Name, Message: "Only instances have properties."})
+	panic(rt2.RuntimeError{Token: expr.Name, Code: rt2.CodeNotInstance, Message: "Only instances have properties."})
 }
 
 func (i *Interpreter) VisitGroupingExpr(expr *expr.Grouping) Object {
@@ -298,12 +553,17 @@ func (i *Interpreter) VisitLogicalExpr(expr *expr.Logical) Object {
 func (i *Interpreter) VisitSetExpr(expr *expr.Set) Object {
 	object := i.evaluate(expr.Object)
 
-	instance, ok := object.(*LoxInstance)
+	instance, ok := object.(propertySetter)
 	if !ok {
-		panic(rt2.RuntimeError{expr.Name, "Only instances have fields."})
+		panic(rt2.RuntimeError{Token: expr.Name, Code: rt2.CodeNotInstance, Message: "Only instances have fields."})
 	}
 
 	value := i.evaluate(expr.Value)
+	if o, ok := instance.(*LoxInstance); ok {
+		if _, ok := o.fields[expr.Name.Lexeme]; !ok {
+			i.charge(fieldSize, nil, expr.Name)
+		}
+	}
 	instance.Set(expr.Name, value)
 	return value
 }
@@ -317,8 +577,7 @@ func (i *Interpreter) VisitSuperExpr(expr *expr.Super) Object {
 	method := superclass.FindMethod(expr.Method.Lexeme)
 
 	if method == nil {
-		panic(rt2.RuntimeError{expr.Method,
-			"Undefined property '" + expr.Method.Lexeme + "'."})
+		panic(undefinedProperty(expr.Method))
 	}
 
 	return method.Bind(object)
@@ -352,7 +611,7 @@ func checkNumberOperand(operator token.Token, operand Object) {
 		return
 	}
 
-	panic(rt2.RuntimeError{operator, "Operand must be a number."})
+	panic(rt2.RuntimeError{Token: operator, Code: rt2.CodeOperandType, Message: "Operand must be a number."})
 }
 
 func (i *Interpreter) lookUpVariable(name token.Token, expr expr.Expr) Object {
@@ -380,23 +639,40 @@ func checkNumberOperands(operator token.Token, left Object, right Object) {
 	if ok1 && ok2 {
 		return
 	}
-	panic(rt2.RuntimeError{Token: operator, Message: "Operands must be numbers."})
+	panic(rt2.RuntimeError{Token: operator, Code: rt2.CodeOperandType, Message: "Operands must be numbers."})
 }
 
-func stringify(object Object) string {
-	if object == nil {
+// typeName describes the type of a value for error messages.
+func typeName(value Object) string {
+	switch value.(type) {
+	case nil:
 		return "nil"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Boolean:
+		return "boolean"
+	case *LoxList:
+		return "list"
+	case *LoxMap:
+		return "map"
+	case *LoxClass:
+		return "class"
+	case *LoxInstance:
+		return "instance"
+	case *LoxModule:
+		return "module"
+	case *LoxFile:
+		return "file"
+	case *LoxRegex:
+		return "regex"
+	case *LoxTime:
+		return "time"
+	case LoxCallable:
+		return "function"
 	}
-
-	if _, ok := object.(Number); ok {
-		text := object.ToString()
-		if strings.HasSuffix(text, ".0") {
-			text = text[0 : len(text)-2]
-		}
-		return text
-	}
-
-	return object.ToString()
+	return "object"
 }
 
 func isTruthy(object Object) bool {
@@ -0,0 +1,205 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"golox/object"
+	"golox/rt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIndent is the widest numeric indent json.stringify accepts.
+const maxIndent = 10
+
+// jsonModule builds the json namespace. Objects parse to maps that keep
+// the order of their keys.
+func jsonModule() *LoxModule {
+	module := NewLoxModule("json")
+	module.Function("parse", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		decoder := json.NewDecoder(strings.NewReader(interpreter.stringArgument(arguments, 0)))
+		decoder.UseNumber()
+		value := interpreter.parseJSON(decoder)
+		if _, err := decoder.Token(); err != io.EOF {
+			panic(interpreter.nativeError(rt.CodeInvalidJSON, "Invalid JSON: unexpected data after the top-level value."))
+		}
+		return value
+	})
+	module.Function("stringify", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		interpreter.argumentCount(arguments, 1, 2)
+		indent := ""
+		if len(arguments) == 2 {
+			switch value := arguments[1].(type) {
+			case nil:
+			case object.String:
+				indent = string(value)
+			default:
+				width := interpreter.integerArgument(arguments, 1)
+				if width < 0 || width > maxIndent {
+					panic(interpreter.NativeError("Indent must be between 0 and " + strconv.Itoa(maxIndent) + " spaces."))
+				}
+				indent = strings.Repeat(" ", width)
+			}
+		}
+
+		var out bytes.Buffer
+		interpreter.writeJSON(&out, arguments[0], make(map[object.Object]bool))
+		if indent == "" {
+			return interpreter.newString(out.String())
+		}
+		var indented bytes.Buffer
+		_ = json.Indent(&indented, out.Bytes(), "", indent)
+		return interpreter.newString(indented.String())
+	})
+	return module
+}
+
+func (i *Interpreter) parseJSON(decoder *json.Decoder) object.Object {
+	tok, err := decoder.Token()
+	if err != nil {
+		i.jsonError(err)
+	}
+
+	switch tok := tok.(type) {
+	case nil:
+		return nil
+	case bool:
+		return object.Boolean(tok)
+	case json.Number:
+		number, err := tok.Float64()
+		if err != nil {
+			i.jsonError(err)
+		}
+		return object.Number(number)
+	case string:
+		return i.newString(tok)
+	case json.Delim:
+		if tok == '[' {
+			i.chargeCollection(listSize)
+			elements := make([]object.Object, 0)
+			for decoder.More() {
+				i.chargeCollection(elementSize)
+				elements = append(elements, i.parseJSON(decoder))
+			}
+			_, _ = decoder.Token()
+			return NewLoxList(elements)
+		}
+
+		i.chargeCollection(mapSize)
+		m := NewLoxMap()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				i.jsonError(err)
+			}
+			i.chargeCollection(entrySize)
+			m.Put(i.newString(key.(string)), i.parseJSON(decoder))
+		}
+		_, _ = decoder.Token()
+		return m
+	}
+	panic(i.nativeError(rt.CodeInvalidJSON, "Invalid JSON."))
+}
+
+func (i *Interpreter) jsonError(err error) {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		panic(i.nativeError(rt.CodeInvalidJSON, "Invalid JSON at offset "+strconv.FormatInt(syntax.Offset, 10)+": "+syntax.Error()+"."))
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		panic(i.nativeError(rt.CodeInvalidJSON, "Invalid JSON: unexpected end of input."))
+	}
+	panic(i.nativeError(rt.CodeInvalidJSON, "Invalid JSON: "+err.Error()+"."))
+}
+
+// writeJSON encodes value compactly. seen holds the collections and
+// instances being written, to catch cycles.
+func (i *Interpreter) writeJSON(out *bytes.Buffer, value object.Object, seen map[object.Object]bool) {
+	switch value := value.(type) {
+	case nil:
+		out.WriteString("null")
+		return
+	case object.Boolean:
+		if value {
+			out.WriteString("true")
+		} else {
+			out.WriteString("false")
+		}
+		return
+	case object.Number:
+		if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+			panic(i.NativeError("Can't convert " + stringify(value) + " to JSON."))
+		}
+		encoded, _ := json.Marshal(float64(value))
+		out.Write(encoded)
+		return
+	case object.String:
+		encoded, _ := json.Marshal(string(value))
+		out.Write(encoded)
+		return
+	case *LoxList, *LoxMap, *LoxInstance:
+	default:
+		panic(i.NativeError("Can't convert " + typeName(value) + " to JSON."))
+	}
+
+	if seen[value] {
+		panic(i.NativeError("Can't convert a cyclic structure to JSON."))
+	}
+	if len(seen) >= maxPrintDepth {
+		panic(i.nativeError(rt.CodeNestingDepth, "Value is nested too deeply to convert to JSON."))
+	}
+	seen[value] = true
+	defer delete(seen, value)
+
+	switch value := value.(type) {
+	case *LoxList:
+		out.WriteByte('[')
+		for n, element := range value.Elements {
+			if n > 0 {
+				out.WriteByte(',')
+			}
+			i.writeJSON(out, element, seen)
+		}
+		out.WriteByte(']')
+	case *LoxMap:
+		out.WriteByte('{')
+		for n, key := range value.Keys() {
+			name, ok := key.(object.String)
+			if !ok {
+				panic(i.NativeError("Can't convert a map with " + typeName(key) + " keys to JSON."))
+			}
+			if n > 0 {
+				out.WriteByte(',')
+			}
+			element, _ := value.Lookup(key)
+			i.writeJSONField(out, string(name), element, seen)
+		}
+		out.WriteByte('}')
+	case *LoxInstance:
+		names := make([]string, 0, len(value.Fields()))
+		for name := range value.Fields() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		out.WriteByte('{')
+		for n, name := range names {
+			if n > 0 {
+				out.WriteByte(',')
+			}
+			i.writeJSONField(out, name, value.Fields()[name], seen)
+		}
+		out.WriteByte('}')
+	}
+}
+
+func (i *Interpreter) writeJSONField(out *bytes.Buffer, name string, value object.Object, seen map[object.Object]bool) {
+	encoded, _ := json.Marshal(name)
+	out.Write(encoded)
+	out.WriteByte(':')
+	i.writeJSON(out, value, seen)
+}
@@ -0,0 +1,67 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+	"math"
+)
+
+type LoxList struct {
+	Elements []object.Object
+}
+
+func NewLoxList(elements []object.Object) *LoxList {
+	return &LoxList{Elements: elements}
+}
+
+func (l *LoxList) Get(name token.Token) object.Object {
+	switch name.Lexeme {
+	case "len":
+		return NewNative("len", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Number(len(l.Elements))
+		})
+	case "get":
+		return NewNative("get", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return l.Elements[l.index(interpreter, arguments[0])]
+		})
+	case "set":
+		return NewNative("set", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			l.Elements[l.index(interpreter, arguments[0])] = arguments[1]
+			return arguments[1]
+		})
+	case "push":
+		return NewNative("push", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			interpreter.chargeCollection(elementSize)
+			l.Elements = append(l.Elements, arguments[0])
+			return nil
+		})
+	case "pop":
+		return NewNative("pop", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			if len(l.Elements) == 0 {
+				panic(interpreter.NativeError("Can't pop from an empty list."))
+			}
+			last := l.Elements[len(l.Elements)-1]
+			l.Elements = l.Elements[:len(l.Elements)-1]
+			return last
+		})
+	}
+
+	panic(undefinedProperty(name))
+}
+
+// index checks that value is a whole number addressing an element.
+func (l *LoxList) index(interpreter *Interpreter, value object.Object) int {
+	n, ok := value.(object.Number)
+	if !ok || n != object.Number(math.Trunc(float64(n))) {
+		panic(interpreter.nativeError(rt.CodeArgumentType, "List index must be an integer."))
+	}
+	if n < 0 || float64(n) >= float64(len(l.Elements)) {
+		panic(interpreter.nativeError(rt.CodeIndexOutOfRange, "List index out of range."))
+	}
+	return int(n)
+}
+
+func (l *LoxList) ToString() string {
+	return stringify(l)
+}
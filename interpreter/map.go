@@ -0,0 +1,98 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/token"
+)
+
+// LoxMap is a hash map that remembers the order in which keys were added.
+type LoxMap struct {
+	keys   []object.Object
+	values map[object.Object]object.Object
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{keys: make([]object.Object, 0), values: make(map[object.Object]object.Object)}
+}
+
+func (m *LoxMap) Keys() []object.Object {
+	return m.keys
+}
+
+func (m *LoxMap) Lookup(key object.Object) (object.Object, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *LoxMap) Put(key object.Object, value object.Object) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *LoxMap) Remove(key object.Object) bool {
+	if _, ok := m.values[key]; !ok {
+		return false
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (m *LoxMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *LoxMap) Get(name token.Token) object.Object {
+	switch name.Lexeme {
+	case "len":
+		return NewNative("len", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Number(m.Len())
+		})
+	case "get":
+		return NewNative("get", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return m.values[arguments[0]]
+		})
+	case "set":
+		return NewNative("set", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			if _, ok := m.values[arguments[0]]; !ok {
+				interpreter.chargeCollection(entrySize)
+			}
+			m.Put(arguments[0], arguments[1])
+			return arguments[1]
+		})
+	case "has":
+		return NewNative("has", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			_, ok := m.values[arguments[0]]
+			return object.Boolean(ok)
+		})
+	case "remove":
+		return NewNative("remove", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(m.Remove(arguments[0]))
+		})
+	case "keys":
+		return NewNative("keys", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return NewLoxList(append([]object.Object(nil), m.keys...))
+		})
+	case "values":
+		return NewNative("values", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			values := make([]object.Object, 0, len(m.keys))
+			for _, key := range m.keys {
+				values = append(values, m.values[key])
+			}
+			return NewLoxList(values)
+		})
+	}
+
+	panic(undefinedProperty(name))
+}
+
+func (m *LoxMap) ToString() string {
+	return stringify(m)
+}
@@ -0,0 +1,90 @@
+package interpreter
+
+import (
+	"golox/object"
+	"math"
+	"math/rand/v2"
+)
+
+// mathModule builds the math namespace. Its random numbers come from the
+// interpreter's own generator, which math.seed makes reproducible.
+func mathModule() *LoxModule {
+	module := NewLoxModule("math")
+	module.Define("pi", object.Number(math.Pi))
+	module.Define("e", object.Number(math.E))
+	module.Define("inf", object.Number(math.Inf(1)))
+	module.Define("nan", object.Number(math.NaN()))
+
+	unary := map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"abs":   math.Abs,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+	}
+	for name, function := range unary {
+		module.Function(name, 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Number(function(interpreter.numberArgument(arguments, 0)))
+		})
+	}
+
+	module.Function("pow", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Number(math.Pow(interpreter.numberArgument(arguments, 0), interpreter.numberArgument(arguments, 1)))
+	})
+	module.Function("atan2", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Number(math.Atan2(interpreter.numberArgument(arguments, 0), interpreter.numberArgument(arguments, 1)))
+	})
+	module.Function("min", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Number(interpreter.fold(arguments, math.Min))
+	})
+	module.Function("max", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Number(interpreter.fold(arguments, math.Max))
+	})
+	module.Function("isNaN", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Boolean(math.IsNaN(interpreter.numberArgument(arguments, 0)))
+	})
+
+	module.Function("seed", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		interpreter.random = newRandom(uint64(interpreter.integerArgument(arguments, 0)))
+		return nil
+	})
+	module.Function("random", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Number(interpreter.random.Float64())
+	})
+	module.Function("randomInt", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		low := interpreter.integerArgument(arguments, 0)
+		high := interpreter.integerArgument(arguments, 1)
+		if high <= low {
+			panic(interpreter.NativeError("Upper bound must be greater than lower bound."))
+		}
+		// high-low can overflow int, but always fits in a uint64.
+		width := uint64(high) - uint64(low)
+		return object.Number(int(uint64(low) + interpreter.random.Uint64N(width)))
+	})
+	return module
+}
+
+// fold combines one or more number arguments with function.
+func (i *Interpreter) fold(arguments []object.Object, function func(float64, float64) float64) float64 {
+	i.argumentCount(arguments, 1, -1)
+	result := i.numberArgument(arguments, 0)
+	for n := 1; n < len(arguments); n++ {
+		result = function(result, i.numberArgument(arguments, n))
+	}
+	return result
+}
+
+// newRandom returns a generator whose sequence is fixed by seed.
+func newRandom(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
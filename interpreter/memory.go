@@ -0,0 +1,77 @@
+package interpreter
+
+import (
+	rt2 "golox/rt"
+	"golox/token"
+)
+
+// Approximate sizes, in bytes, charged against Interpreter.MaxMemory. They
+// track the Go representation loosely; the aim is to stop runaway growth,
+// not to mirror the garbage collector. Environments, and the variables in
+// them, are credited back when their block or call returns, so loops and
+// calls don't count against the limit as they run; an environment kept
+// alive by a closure is undercounted.
+const (
+	stringSize      = 16
+	instanceSize    = 64
+	fieldSize       = 48
+	environmentSize = 64
+	variableSize    = 48
+	listSize        = 48
+	elementSize     = 16
+	mapSize         = 64
+	entrySize       = 48
+)
+
+// Stats counts what a run has allocated. Bytes is the approximate total,
+// including memory since credited back; the other fields count
+// allocations of each kind.
+type Stats struct {
+	Bytes        uint64
+	Strings      uint64
+	Instances    uint64
+	Environments uint64
+	Collections  uint64
+}
+
+func (i *Interpreter) Stats() Stats {
+	return i.stats
+}
+
+// ResetStats starts fresh allocation statistics for the next run.
+func (i *Interpreter) ResetStats() {
+	i.stats = Stats{}
+	i.live = 0
+}
+
+// charge adds bytes to the totals and bumps counter, stopping the run at
+// the given token once the live total exceeds MaxMemory.
+func (i *Interpreter) charge(bytes uint64, counter *uint64, at token.Token) {
+	i.stats.Bytes += bytes
+	i.live += bytes
+	if counter != nil {
+		*counter++
+	}
+	if i.MaxMemory > 0 && i.live > i.MaxMemory {
+		panic(stop(at, rt2.CodeMemoryLimit, "memory limit exceeded", rt2.ErrMemoryLimit))
+	}
+}
+
+func (i *Interpreter) chargeString(length int, at token.Token) {
+	i.charge(stringSize+uint64(length), &i.stats.Strings, at)
+}
+
+func (i *Interpreter) chargeEnvironment(variables int, at token.Token) {
+	i.charge(environmentSize+uint64(variables)*variableSize, &i.stats.Environments, at)
+}
+
+// release credits back an environment charged by chargeEnvironment, along
+// with the variables defined in it, once its block or call has returned.
+func (i *Interpreter) release(environment *rt2.Environment) {
+	bytes := environmentSize + uint64(environment.Len())*variableSize
+	i.live -= min(bytes, i.live)
+}
+
+func (i *Interpreter) chargeCollection(bytes uint64) {
+	i.charge(bytes, &i.stats.Collections, i.callSite)
+}
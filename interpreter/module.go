@@ -0,0 +1,44 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+)
+
+// LoxModule is a namespace of natives and constants, such as math, whose
+// members are read as properties.
+type LoxModule struct {
+	Name    string
+	members map[string]object.Object
+}
+
+func NewLoxModule(name string) *LoxModule {
+	return &LoxModule{Name: name, members: make(map[string]object.Object)}
+}
+
+// Define adds a member to the module.
+func (m *LoxModule) Define(name string, value object.Object) *LoxModule {
+	m.members[name] = value
+	return m
+}
+
+// Function adds a native member, named after the module in stack traces.
+func (m *LoxModule) Function(name string, arity int,
+	function func(interpreter *Interpreter, arguments []object.Object) object.Object) *Native {
+	native := NewNative(m.Name+"."+name, arity, function)
+	m.members[name] = native
+	return native
+}
+
+func (m *LoxModule) Get(name token.Token) object.Object {
+	if member, ok := m.members[name.Lexeme]; ok {
+		return member
+	}
+
+	panic(rt.RuntimeError{Token: name, Code: rt.CodeUndefinedProperty, Message: "Undefined property '" + name.Lexeme + "' in module " + m.Name + "."})
+}
+
+func (m *LoxModule) ToString() string {
+	return "<module " + m.Name + ">"
+}
@@ -5,13 +5,23 @@ import (
 )
 
 type Native struct {
+	// Name identifies the native in stack traces.
+	Name     string
 	arity    int
+	requires []Capability
 	Function func(interpreter *Interpreter, arguments []object.Object) object.Object
 }
 
-func NewNative(arity int,
+func NewNative(name string, arity int,
 	function func(interpreter *Interpreter, arguments []object.Object) object.Object) *Native {
-	return &Native{arity: arity, Function: function}
+	return &Native{Name: name, arity: arity, Function: function}
+}
+
+// Requires declares the capabilities the native needs; calling it without
+// them raises a runtime error naming the missing one.
+func (n *Native) Requires(capabilities ...Capability) *Native {
+	n.requires = append(n.requires, capabilities...)
+	return n
 }
 
 func (n *Native) Arity() int {
@@ -19,7 +29,13 @@ func (n *Native) Arity() int {
 }
 
 func (n *Native) Call(interpreter *Interpreter, arguments []object.Object) object.Object {
-	return n.Function(interpreter, arguments)
+	for _, capability := range n.requires {
+		interpreter.require(capability)
+	}
+	interpreter.enter(n.Name)
+	result := n.Function(interpreter, arguments)
+	interpreter.leave()
+	return result
 }
 
 func (n *Native) ToString() string {
@@ -0,0 +1,76 @@
+package interpreter
+
+import (
+	"golox/object"
+	"io"
+	"os"
+)
+
+// osModule builds the os namespace. os.args is empty until the host calls
+// SetArgs; reading and changing the environment needs the env capability,
+// and running programs with os.exec the exec capability.
+func osModule() *LoxModule {
+	module := NewLoxModule("os")
+	module.Define("args", NewLoxList(make([]object.Object, 0)))
+	module.Function("env", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		value, ok := os.LookupEnv(interpreter.stringArgument(arguments, 0))
+		if !ok {
+			return nil
+		}
+		return interpreter.newString(value)
+	}).Requires(Env)
+	module.Function("setEnv", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		err := os.Setenv(interpreter.stringArgument(arguments, 0), interpreter.stringArgument(arguments, 1))
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return nil
+	}).Requires(Env)
+	module.Function("cwd", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		dir, err := os.Getwd()
+		if err != nil {
+			panic(interpreter.hostError(err))
+		}
+		return interpreter.newString(dir)
+	}).Requires(Env)
+	module.Function("exec", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return interpreter.execCommand(arguments)
+	}).Requires(Exec)
+	return module
+}
+
+// SetArgs sets the script arguments listed by os.args.
+func (i *Interpreter) SetArgs(args []string) {
+	elements := make([]object.Object, 0, len(args))
+	for _, arg := range args {
+		elements = append(elements, object.String(arg))
+	}
+	i.os.Define("args", NewLoxList(elements))
+}
+
+// exit is the panic value raised by the exit native. It unwinds the whole
+// run and is turned into the interpreter's exit status by catch.
+type exit struct {
+	code int
+}
+
+// Exited reports whether the last run ended by calling exit, and with
+// which status, and clears that state for the next run.
+func (i *Interpreter) Exited() (code int, ok bool) {
+	if i.exit == nil {
+		return 0, false
+	}
+	code = i.exit.code
+	i.exit = nil
+	return code, true
+}
+
+// flush writes out any output the streams are still buffering, for
+// writers such as bufio.Writer.
+func (i *Interpreter) flush() {
+	for _, w := range []io.Writer{i.Stdout, i.Stderr} {
+		if f, ok := w.(interface{ Flush() error }); ok {
+			_ = f.Flush()
+		}
+	}
+}
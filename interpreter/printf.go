@@ -0,0 +1,109 @@
+package interpreter
+
+import (
+	"fmt"
+	"golox/object"
+	"golox/rt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sprintf formats arguments according to a printf-style template. Each
+// directive is %[flags][width][.precision]verb with the flags -, +, space,
+// 0 and #. The verbs are d, x, X, o and b for integers, f, e, E, g and G
+// for numbers, s and q for strings, v for any value as print shows it,
+// and %% for a percent sign.
+func (i *Interpreter) sprintf(template string, arguments []object.Object) string {
+	var out strings.Builder
+	next := 0
+	for n := 0; n < len(template); n++ {
+		if template[n] != '%' {
+			out.WriteByte(template[n])
+			continue
+		}
+
+		start := n
+		n++
+		for n < len(template) && strings.IndexByte("-+ 0#", template[n]) >= 0 {
+			n++
+		}
+		n = i.formatWidth(template, start, n)
+		if n < len(template) && template[n] == '.' {
+			n = i.formatWidth(template, start, n+1)
+		}
+		if n >= len(template) {
+			panic(i.nativeError(rt.CodeInvalidFormat, "Incomplete directive '"+template[start:]+"' in format string."))
+		}
+
+		verb := template[n]
+		spec := template[start : n+1]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if next >= len(arguments) {
+			panic(i.nativeError(rt.CodeInvalidFormat, "Format string needs more arguments than were given."))
+		}
+		argument := arguments[next]
+		next++
+
+		switch verb {
+		case 'd', 'x', 'X', 'o', 'b':
+			number, ok := argument.(object.Number)
+			if !ok || float64(number) != math.Trunc(float64(number)) ||
+				float64(number) < math.MinInt64 || float64(number) >= -math.MinInt64 {
+				got := typeName(argument)
+				if ok {
+					got = stringify(number)
+				}
+				panic(i.nativeError(rt.CodeArgumentType, "Directive '"+spec+"' expects an integer but got "+got+"."))
+			}
+			out.WriteString(fmt.Sprintf(spec, int64(number)))
+		case 'f', 'e', 'E', 'g', 'G':
+			number, ok := argument.(object.Number)
+			if !ok {
+				panic(i.nativeError(rt.CodeArgumentType, "Directive '"+spec+"' expects a number but got "+typeName(argument)+"."))
+			}
+			out.WriteString(fmt.Sprintf(spec, float64(number)))
+		case 's', 'q':
+			s, ok := argument.(object.String)
+			if !ok {
+				panic(i.nativeError(rt.CodeArgumentType, "Directive '"+spec+"' expects a string but got "+typeName(argument)+"."))
+			}
+			out.WriteString(fmt.Sprintf(spec, string(s)))
+		case 'v':
+			out.WriteString(fmt.Sprintf(spec[:len(spec)-1]+"s", i.display(argument, i.callSite)))
+		default:
+			panic(i.nativeError(rt.CodeInvalidFormat, "Unknown directive '"+spec+"' in format string."))
+		}
+	}
+
+	if next < len(arguments) {
+		panic(i.nativeError(rt.CodeInvalidFormat, "Format string uses "+strconv.Itoa(next)+" arguments but was given "+
+			strconv.Itoa(len(arguments))+"."))
+	}
+	return out.String()
+}
+
+// maxFormatWidth bounds the width and precision of a directive. fmt
+// prints %!(NOVERB) for numbers beyond its own limit instead.
+const maxFormatWidth = 1000
+
+// formatWidth skips the run of digits at template[n:], which is a width or
+// precision in the directive starting at start, and returns where it ends.
+func (i *Interpreter) formatWidth(template string, start int, n int) int {
+	from := n
+	for n < len(template) && isDigit(template[n]) {
+		n++
+	}
+	if width, err := strconv.Atoi(template[from:n]); n > from && (err != nil || width > maxFormatWidth) {
+		panic(i.nativeError(rt.CodeInvalidFormat, "Width or precision in directive '"+template[start:n]+
+			"' is larger than "+strconv.Itoa(maxFormatWidth)+"."))
+	}
+	return n
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,128 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/token"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// reModule builds the re namespace around Go's regexp syntax.
+func reModule() *LoxModule {
+	module := NewLoxModule("re")
+	module.Function("compile", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		pattern := interpreter.stringArgument(arguments, 0)
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			panic(interpreter.NativeError("Invalid regular expression: " + err.Error() + "."))
+		}
+		return &LoxRegex{regexp: compiled}
+	})
+	return module
+}
+
+// LoxRegex is a compiled regular expression. Matches are maps holding the
+// matched text, its code point index, the list of groups and a map of the
+// named groups; groups that took no part in the match are nil.
+type LoxRegex struct {
+	regexp *regexp.Regexp
+}
+
+func (r *LoxRegex) Get(name token.Token) object.Object {
+	switch name.Lexeme {
+	case "pattern":
+		return object.String(r.regexp.String())
+	case "test":
+		return NewNative("test", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(r.regexp.MatchString(interpreter.stringArgument(arguments, 0)))
+		})
+	case "find":
+		return NewNative("find", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			text := interpreter.stringArgument(arguments, 0)
+			location := r.regexp.FindStringSubmatchIndex(text)
+			if location == nil {
+				return nil
+			}
+			return r.match(interpreter, text, location)
+		})
+	case "findAll":
+		return NewNative("findAll", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			text := interpreter.stringArgument(arguments, 0)
+			locations := r.regexp.FindAllStringSubmatchIndex(text, -1)
+			interpreter.chargeCollection(listSize + uint64(len(locations))*elementSize)
+			matches := make([]object.Object, 0, len(locations))
+			for _, location := range locations {
+				matches = append(matches, r.match(interpreter, text, location))
+			}
+			return NewLoxList(matches)
+		})
+	case "replace":
+		return NewNative("replace", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			text := interpreter.stringArgument(arguments, 0)
+			switch replacement := arguments[1].(type) {
+			case object.String:
+				return interpreter.newString(r.regexp.ReplaceAllString(text, string(replacement)))
+			case LoxCallable:
+				if replacement.Arity() != 1 && replacement.Arity() != -1 {
+					panic(interpreter.NativeError("Replacement function must take 1 argument."))
+				}
+				return interpreter.newString(r.replaceFunc(interpreter, text, replacement))
+			}
+			interpreter.argumentError(1, "string or function", arguments[1])
+			return nil
+		})
+	case "split":
+		return NewNative("split", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.stringList(r.regexp.Split(interpreter.stringArgument(arguments, 0), -1))
+		})
+	}
+
+	panic(undefinedProperty(name))
+}
+
+// replaceFunc replaces each match with the stringified result of calling
+// function with the match.
+func (r *LoxRegex) replaceFunc(interpreter *Interpreter, text string, function LoxCallable) string {
+	var out strings.Builder
+	last := 0
+	for _, location := range r.regexp.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(text[last:location[0]])
+		result := function.Call(interpreter, []object.Object{r.match(interpreter, text, location)})
+		out.WriteString(interpreter.display(result, interpreter.callSite))
+		last = location[1]
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// match builds the map describing the match at location, as returned by
+// FindStringSubmatchIndex.
+func (r *LoxRegex) match(interpreter *Interpreter, text string, location []int) *LoxMap {
+	names := r.regexp.SubexpNames()
+	interpreter.chargeCollection(2*mapSize + listSize + uint64(len(names)+3)*entrySize)
+
+	groups := make([]object.Object, 0, len(names)-1)
+	named := NewLoxMap()
+	for n := 1; n < len(names); n++ {
+		var group object.Object
+		if location[2*n] >= 0 {
+			group = interpreter.newString(text[location[2*n]:location[2*n+1]])
+		}
+		groups = append(groups, group)
+		if names[n] != "" {
+			named.Put(object.String(names[n]), group)
+		}
+	}
+
+	match := NewLoxMap()
+	match.Put(object.String("match"), interpreter.newString(text[location[0]:location[1]]))
+	match.Put(object.String("index"), object.Number(utf8.RuneCountInString(text[:location[0]])))
+	match.Put(object.String("groups"), NewLoxList(groups))
+	match.Put(object.String("named"), named)
+	return match
+}
+
+func (r *LoxRegex) ToString() string {
+	return "<regex " + r.regexp.String() + ">"
+}
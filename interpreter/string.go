@@ -0,0 +1,153 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringMethod looks up a built-in method on a string. Lengths and
+// indexes count code points, not bytes.
+func (i *Interpreter) stringMethod(s object.String, name token.Token) object.Object {
+	text := string(s)
+	switch name.Lexeme {
+	case "len":
+		return NewNative("len", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Number(utf8.RuneCountInString(text))
+		})
+	case "upper":
+		return NewNative("upper", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.newString(strings.ToUpper(text))
+		})
+	case "lower":
+		return NewNative("lower", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.newString(strings.ToLower(text))
+		})
+	case "trim":
+		return NewNative("trim", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.newString(strings.TrimSpace(text))
+		})
+	case "split":
+		return NewNative("split", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			parts := strings.Split(text, interpreter.stringArgument(arguments, 0))
+			return interpreter.stringList(parts)
+		})
+	case "replace":
+		return NewNative("replace", 2, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			old := interpreter.stringArgument(arguments, 0)
+			replacement := interpreter.stringArgument(arguments, 1)
+			return interpreter.newString(strings.ReplaceAll(text, old, replacement))
+		})
+	case "contains":
+		return NewNative("contains", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(strings.Contains(text, interpreter.stringArgument(arguments, 0)))
+		})
+	case "startsWith":
+		return NewNative("startsWith", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(strings.HasPrefix(text, interpreter.stringArgument(arguments, 0)))
+		})
+	case "indexOf":
+		return NewNative("indexOf", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			index := strings.Index(text, interpreter.stringArgument(arguments, 0))
+			if index < 0 {
+				return object.Number(-1)
+			}
+			return object.Number(utf8.RuneCountInString(text[:index]))
+		})
+	case "substring":
+		return NewNative("substring", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			interpreter.argumentCount(arguments, 1, 2)
+			runes := []rune(text)
+			start := interpreter.integerArgument(arguments, 0)
+			end := len(runes)
+			if len(arguments) == 2 {
+				end = interpreter.integerArgument(arguments, 1)
+			}
+			if start < 0 || end > len(runes) || start > end {
+				panic(interpreter.nativeError(rt.CodeIndexOutOfRange, "String index out of range."))
+			}
+			return interpreter.newString(string(runes[start:end]))
+		})
+	case "repeat":
+		return NewNative("repeat", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			count := interpreter.integerArgument(arguments, 0)
+			if count < 0 {
+				panic(interpreter.nativeError(rt.CodeArgumentType, "Repeat count must not be negative."))
+			}
+			if count > 0 && len(text) > int(^uint(0)>>1)/count {
+				panic(interpreter.nativeError(rt.CodeArgumentType, "Repeated string is too long."))
+			}
+			interpreter.chargeString(len(text)*count, interpreter.callSite)
+			return object.String(strings.Repeat(text, count))
+		})
+	case "chars":
+		return NewNative("chars", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.stringList(strings.Split(text, ""))
+		})
+	case "format":
+		return NewNative("format", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.newString(interpreter.format(text, arguments))
+		})
+	}
+
+	panic(undefinedProperty(name))
+}
+
+// newString charges for a string built by a native.
+func (i *Interpreter) newString(s string) object.String {
+	i.chargeString(len(s), i.callSite)
+	return object.String(s)
+}
+
+func (i *Interpreter) stringList(parts []string) *LoxList {
+	i.chargeCollection(uint64(len(parts)) * elementSize)
+	elements := make([]object.Object, 0, len(parts))
+	for _, part := range parts {
+		elements = append(elements, i.newString(part))
+	}
+	return NewLoxList(elements)
+}
+
+// format replaces each {} in template with the next argument, and each
+// {n} with argument n counting from zero, using the same rules as print.
+// {{ and }} stand for literal braces.
+func (i *Interpreter) format(template string, arguments []object.Object) string {
+	var out strings.Builder
+	next := 0
+	for n := 0; n < len(template); n++ {
+		c := template[n]
+		if (c == '{' || c == '}') && n+1 < len(template) && template[n+1] == c {
+			out.WriteByte(c)
+			n++
+			continue
+		}
+		if c != '{' {
+			out.WriteByte(c)
+			continue
+		}
+
+		end := strings.IndexByte(template[n:], '}')
+		if end < 0 {
+			panic(i.nativeError(rt.CodeInvalidFormat, "Unclosed '{' in format string."))
+		}
+		index := next
+		if field := template[n+1 : n+end]; field != "" {
+			parsed, err := strconv.Atoi(field)
+			if err != nil {
+				panic(i.nativeError(rt.CodeInvalidFormat, "Invalid placeholder '{"+field+"}' in format string."))
+			}
+			index = parsed
+		} else {
+			next++
+		}
+		if index < 0 || index >= len(arguments) {
+			panic(i.nativeError(rt.CodeInvalidFormat, "Format string needs more arguments than were given."))
+		}
+		out.WriteString(i.display(arguments[index], i.callSite))
+		n += end
+	}
+	return out.String()
+}
@@ -0,0 +1,107 @@
+package interpreter
+
+import (
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+	"strings"
+)
+
+// maxPrintDepth bounds how deeply nested collections are printed, keeping
+// the Go stack well clear of its limit.
+const maxPrintDepth = 1000
+
+// stringify renders a value the way print shows it. A collection that
+// contains itself prints the repeat as [...] or {...}, and nesting deeper
+// than maxPrintDepth is cut short with "...".
+func stringify(value object.Object) string {
+	p := printer{seen: make(map[object.Object]bool)}
+	p.write(value, 0, false)
+	return p.out.String()
+}
+
+// quote renders a collection element, quoting strings so that ["1"] and
+// [1] print differently.
+func quote(value object.Object) string {
+	p := printer{seen: make(map[object.Object]bool)}
+	p.write(value, 0, true)
+	return p.out.String()
+}
+
+// display is stringify for output the script asked for, which raises a
+// runtime error at the given token rather than print a value nested too
+// deeply to show in full.
+func (i *Interpreter) display(value object.Object, at token.Token) string {
+	p := printer{seen: make(map[object.Object]bool)}
+	p.write(value, 0, false)
+	if p.truncated {
+		panic(rt.RuntimeError{Token: at, Code: rt.CodeNestingDepth,
+			Message: "Value is nested too deeply to print."})
+	}
+	return p.out.String()
+}
+
+type printer struct {
+	out       strings.Builder
+	seen      map[object.Object]bool
+	truncated bool
+}
+
+func (p *printer) write(value object.Object, depth int, quoted bool) {
+	switch value := value.(type) {
+	case nil:
+		p.out.WriteString("nil")
+	case object.Number:
+		p.out.WriteString(strings.TrimSuffix(value.ToString(), ".0"))
+	case object.String:
+		if quoted {
+			p.out.WriteString("\"" + string(value) + "\"")
+		} else {
+			p.out.WriteString(string(value))
+		}
+	case *LoxList:
+		if p.enter(value, depth, "[...]") {
+			p.out.WriteByte('[')
+			for n, element := range value.Elements {
+				if n > 0 {
+					p.out.WriteString(", ")
+				}
+				p.write(element, depth+1, true)
+			}
+			p.out.WriteByte(']')
+			delete(p.seen, value)
+		}
+	case *LoxMap:
+		if p.enter(value, depth, "{...}") {
+			p.out.WriteByte('{')
+			for n, key := range value.keys {
+				if n > 0 {
+					p.out.WriteString(", ")
+				}
+				p.write(key, depth+1, true)
+				p.out.WriteString(": ")
+				p.write(value.values[key], depth+1, true)
+			}
+			p.out.WriteByte('}')
+			delete(p.seen, value)
+		}
+	default:
+		p.out.WriteString(value.ToString())
+	}
+}
+
+// enter reports whether the collection should be written out in full,
+// writing cycle in its place when it is already being written.
+func (p *printer) enter(collection object.Object, depth int, cycle string) bool {
+	if p.seen[collection] {
+		p.out.WriteString(cycle)
+		return false
+	}
+	if depth >= maxPrintDepth {
+		p.out.WriteString("...")
+		p.truncated = true
+		return false
+	}
+	p.seen[collection] = true
+	return true
+}
@@ -0,0 +1,199 @@
+package interpreter
+
+import (
+	"golox/object"
+	rt2 "golox/rt"
+	"golox/token"
+	"math"
+	"time"
+	_ "time/tzdata"
+)
+
+// timeModule builds the time namespace. Timestamps and durations are
+// numbers of milliseconds, as returned by clock; layouts are Go's
+// reference-time layouts, the common ones provided as constants. Zone
+// names come from the tz database compiled into the binary.
+func timeModule() *LoxModule {
+	module := NewLoxModule("time")
+	module.Define("RFC3339", object.String(time.RFC3339))
+	module.Define("RFC1123", object.String(time.RFC1123))
+	module.Define("DateTime", object.String(time.DateTime))
+	module.Define("DateOnly", object.String(time.DateOnly))
+	module.Define("TimeOnly", object.String(time.TimeOnly))
+	module.Define("Kitchen", object.String(time.Kitchen))
+
+	module.Function("now", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return &LoxTime{time: time.Now()}
+	}).Requires(Clock)
+	module.Function("unix", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return &LoxTime{time: time.Unix(0, int64(interpreter.durationArgument(arguments, 0))).UTC()}
+	})
+	module.Function("parse", -1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		interpreter.argumentCount(arguments, 2, 3)
+		location := time.UTC
+		if len(arguments) == 3 {
+			location = interpreter.zoneArgument(arguments, 2)
+		}
+		parsed, err := time.ParseInLocation(interpreter.stringArgument(arguments, 0),
+			interpreter.stringArgument(arguments, 1), location)
+		if err != nil {
+			panic(interpreter.NativeError("Invalid time: " + err.Error() + "."))
+		}
+		return &LoxTime{time: parsed}
+	})
+	module.Function("parseDuration", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		duration, err := time.ParseDuration(interpreter.stringArgument(arguments, 0))
+		if err != nil {
+			panic(interpreter.NativeError("Invalid duration: " + err.Error() + "."))
+		}
+		return milliseconds(duration)
+	})
+	module.Function("formatDuration", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.String(interpreter.durationArgument(arguments, 0).String())
+	})
+	module.Function("sleep", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		interpreter.sleep(interpreter.durationArgument(arguments, 0))
+		return nil
+	})
+	return module
+}
+
+func milliseconds(d time.Duration) object.Number {
+	return object.Number(float64(d) / float64(time.Millisecond))
+}
+
+// duration converts milliseconds to a Duration, reporting false for NaN
+// and for values a Duration can't hold.
+func duration(milliseconds float64) (time.Duration, bool) {
+	nanoseconds := milliseconds * float64(time.Millisecond)
+	if !(nanoseconds >= math.MinInt64 && nanoseconds < -math.MinInt64) {
+		return 0, false
+	}
+	return time.Duration(nanoseconds), true
+}
+
+// durationArgument reads a number of milliseconds as a Duration.
+func (i *Interpreter) durationArgument(arguments []object.Object, n int) time.Duration {
+	d, ok := duration(i.numberArgument(arguments, n))
+	if !ok {
+		i.argumentError(n, "duration in range", arguments[n])
+	}
+	return d
+}
+
+func (i *Interpreter) zoneArgument(arguments []object.Object, n int) *time.Location {
+	name := i.stringArgument(arguments, n)
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(i.NativeError("Unknown time zone '" + name + "'."))
+	}
+	return location
+}
+
+// sleep pauses for d, ending the run early if the deadline passes or the
+// context is cancelled first.
+func (i *Interpreter) sleep(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	var deadline <-chan time.Time
+	if !i.Deadline.IsZero() {
+		deadlineTimer := time.NewTimer(time.Until(i.Deadline))
+		defer deadlineTimer.Stop()
+		deadline = deadlineTimer.C
+	}
+	var done <-chan struct{}
+	if i.Context != nil {
+		done = i.Context.Done()
+	}
+
+	select {
+	case <-timer.C:
+	case <-deadline:
+		panic(stop(i.callSite, rt2.CodeDeadline, "deadline exceeded", rt2.ErrDeadline))
+	case <-done:
+		panic(stop(i.callSite, rt2.CodeCancelled, i.Context.Err().Error(), i.Context.Err()))
+	}
+}
+
+// LoxTime is an instant in a particular time zone.
+type LoxTime struct {
+	time time.Time
+}
+
+func (t *LoxTime) Get(name token.Token) object.Object {
+	switch name.Lexeme {
+	case "timestamp":
+		return NewNative("timestamp", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return milliseconds(time.Duration(t.time.UnixNano()))
+		})
+	case "format":
+		return NewNative("format", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return interpreter.newString(t.time.Format(interpreter.stringArgument(arguments, 0)))
+		})
+	case "add":
+		return NewNative("add", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return &LoxTime{time: t.time.Add(interpreter.durationArgument(arguments, 0))}
+		})
+	case "sub":
+		return NewNative("sub", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return milliseconds(t.time.Sub(interpreter.timeArgument(arguments, 0)))
+		})
+	case "before":
+		return NewNative("before", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(t.time.Before(interpreter.timeArgument(arguments, 0)))
+		})
+	case "after":
+		return NewNative("after", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(t.time.After(interpreter.timeArgument(arguments, 0)))
+		})
+	case "equals":
+		return NewNative("equals", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.Boolean(t.time.Equal(interpreter.timeArgument(arguments, 0)))
+		})
+	case "in":
+		return NewNative("in", 1, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return &LoxTime{time: t.time.In(interpreter.zoneArgument(arguments, 0))}
+		})
+	case "zone":
+		return NewNative("zone", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.String(t.time.Location().String())
+		})
+	case "year":
+		return t.field("year", t.time.Year)
+	case "month":
+		return t.field("month", func() int { return int(t.time.Month()) })
+	case "day":
+		return t.field("day", t.time.Day)
+	case "hour":
+		return t.field("hour", t.time.Hour)
+	case "minute":
+		return t.field("minute", t.time.Minute)
+	case "second":
+		return t.field("second", t.time.Second)
+	case "weekday":
+		return NewNative("weekday", 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+			return object.String(t.time.Weekday().String())
+		})
+	}
+
+	panic(undefinedProperty(name))
+}
+
+func (t *LoxTime) field(name string, value func() int) *Native {
+	return NewNative(name, 0, func(interpreter *Interpreter, arguments []object.Object) object.Object {
+		return object.Number(value())
+	})
+}
+
+func (i *Interpreter) timeArgument(arguments []object.Object, n int) time.Time {
+	t, ok := arguments[n].(*LoxTime)
+	if !ok {
+		i.argumentError(n, "time", arguments[n])
+	}
+	return t.time
+}
+
+func (t *LoxTime) ToString() string {
+	return t.time.Format(time.RFC3339Nano)
+}
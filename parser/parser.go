@@ -9,23 +9,25 @@ import (
 )
 
 type Parser struct {
-	tokens  []token.Token
-	current uint
+	tokens      []token.Token
+	current     uint
+	diagnostics *rt.Diagnostics
 }
 
 func NewParser(tokens []token.Token) *Parser {
 	return &Parser{
-		tokens:  tokens,
-		current: 0,
+		tokens:      tokens,
+		current:     0,
+		diagnostics: rt.NewDiagnostics(),
 	}
 }
 
-func (p *Parser) Parse() []Stmt {
+func (p *Parser) Parse() ([]Stmt, *rt.Diagnostics) {
 	statements := make([]Stmt, 0)
 	for !p.isAtEnd() {
 		statements = append(statements, p.declaration())
 	}
-	return statements
+	return statements, p.diagnostics
 }
 
 func (p *Parser) declaration() Stmt {
@@ -49,6 +51,7 @@ func (p *Parser) declaration() Stmt {
 }
 
 func (p *Parser) forStatement() Stmt {
+	keyword := p.previous()
 	p.consume(token.LeftParen, "Expect '(' after 'for'.")
 
 	var initializer Stmt
@@ -73,42 +76,47 @@ func (p *Parser) forStatement() Stmt {
 	p.consume(token.RightParen, "Expect ')' after for clauses.")
 
 	body := p.statement()
+	span := p.span(keyword)
 
 	if increment != nil {
-		body = &Block{Statements: []Stmt{body, &Expression{Expression: increment}}}
+		body = &Block{Statements: []Stmt{body,
+			&Expression{Expression: increment, Range: increment.Span()}}, Range: span}
 	}
 
 	if condition == nil {
-		condition = &expr.Literal{Value: object.Boolean(true)}
+		condition = &expr.Literal{Value: object.Boolean(true), Range: span}
 	}
-	body = &While{Condition: condition, Body: body}
+	body = &While{Keyword: keyword, Condition: condition, Body: body, Range: span}
 
 	if initializer != nil {
-		body = &Block{Statements: []Stmt{initializer, body}}
+		body = &Block{Statements: []Stmt{initializer, body}, Range: span}
 	}
 
 	return body
 }
 
 func (p *Parser) ifStatement() Stmt {
+	keyword := p.previous()
 	p.consume(token.LeftParen, "Expect '(' after 'if'.")
 	condition := p.expression()
 	p.consume(token.RightParen, "Expect ')' after if condition.")
 
 	thenBranch := p.statement()
 	var elseBranch Stmt = nil
-	if !p.match(token.Else) {
+	if p.match(token.Else) {
 		elseBranch = p.statement()
 	}
 
-	return &If{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}
+	return &If{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch,
+		Range: p.span(keyword)}
 }
 
 func (p *Parser) printStatement() Stmt {
+	keyword := p.previous()
 	value := p.expression()
 
 	p.consume(token.Semicolon, "Expect ';' after value.")
-	return &Print{Expression: value}
+	return &Print{Expression: value, Range: p.span(keyword)}
 }
 
 func (p *Parser) returnStatement() Stmt {
@@ -120,23 +128,24 @@ func (p *Parser) returnStatement() Stmt {
 	}
 
 	p.consume(token.Semicolon, "Expect ';' after return value.")
-	return &Return{Keyword: keyword, Value: value}
+	return &Return{Keyword: keyword, Value: value, Range: p.span(keyword)}
 }
 
 func (p *Parser) whileStatement() Stmt {
+	keyword := p.previous()
 	p.consume(token.LeftParen, "Expect '(' after 'while'.")
 	condition := p.expression()
 	p.consume(token.RightParen, "Expect ')' after condition.")
 	body := p.statement()
 
-	return &While{condition, body}
+	return &While{Keyword: keyword, Condition: condition, Body: body, Range: p.span(keyword)}
 }
 
 func (p *Parser) expressionStatement() Stmt {
 	expression := p.expression()
 
 	p.consume(token.Semicolon, "Expect ';' after expression.")
-	return &Expression{Expression: expression}
+	return &Expression{Expression: expression, Range: expression.Span().Through(p.previous().Span)}
 }
 
 func (p *Parser) statement() Stmt {
@@ -156,19 +165,22 @@ func (p *Parser) statement() Stmt {
 		return p.whileStatement()
 	}
 	if p.match(token.LeftBrace) {
-		return &Block{Statements: p.block()}
+		brace := p.previous()
+		return &Block{Statements: p.block(), Range: p.span(brace)}
 	}
 
 	return p.expressionStatement()
 }
 
 func (p *Parser) classDeclaration() Stmt {
+	keyword := p.previous()
+	doc := keyword.Doc
 	name := p.consume(token.Identifier, "Expect class name.")
 	var superclass *expr.Variable = nil
 
 	if p.match(token.Less) {
 		p.consume(token.Identifier, "Expect superclass name.")
-		superclass = &expr.Variable{Name: p.previous()}
+		superclass = &expr.Variable{Name: p.previous(), Range: p.previous().Span}
 	}
 
 	p.consume(token.LeftBrace, "Expect '{' before class body.")
@@ -180,10 +192,12 @@ func (p *Parser) classDeclaration() Stmt {
 
 	p.consume(token.RightBrace, "Expect '}' after class body.")
 
-	return &Class{Name: name, Superclass: superclass, Methods: methods}
+	return &Class{Name: name, Superclass: superclass, Methods: methods, Doc: doc,
+		Range: p.span(keyword)}
 }
 
 func (p *Parser) varDeclaration() Stmt {
+	keyword := p.previous()
 	name := p.consume(token.Identifier, "Expect variable name.")
 
 	var initializer expr.Expr = nil
@@ -191,7 +205,7 @@ func (p *Parser) varDeclaration() Stmt {
 		initializer = p.expression()
 	}
 	p.consume(token.Semicolon, "Expect ';' after variable declaration.")
-	return &Var{Name: name, Initializer: initializer}
+	return &Var{Name: name, Initializer: initializer, Range: p.span(keyword)}
 }
 
 func (p *Parser) assignment() expr.Expr {
@@ -203,11 +217,12 @@ func (p *Parser) assignment() expr.Expr {
 
 		if variable, ok := expression.(*expr.Variable); ok {
 			name := variable.Name
-			return &expr.Assign{Name: name, Value: value}
+			return &expr.Assign{Name: name, Value: value, Range: expression.Span().Through(value.Span())}
 		} else if get, ok := expression.(*expr.Get); ok {
-			return &expr.Set{Object: get.Object, Name: get.Name, Value: value}
+			return &expr.Set{Object: get.Object, Name: get.Name, Value: value,
+				Range: expression.Span().Through(value.Span())}
 		}
-		error(equals, "Invalid assignment target.")
+		p.error(equals, rt.CodeInvalidAssignment, "Invalid assignment target.")
 	}
 
 	return expression
@@ -219,7 +234,8 @@ func (p *Parser) or() expr.Expr {
 	for p.match(token.Or) {
 		operator := p.previous()
 		right := p.and()
-		expression = &expr.Logical{Left: expression, Operator: operator, Right: right}
+		expression = &expr.Logical{Left: expression, Operator: operator, Right: right,
+			Range: expression.Span().Through(right.Span())}
 	}
 
 	return expression
@@ -231,7 +247,8 @@ func (p *Parser) and() expr.Expr {
 	for p.match(token.And) {
 		operator := p.previous()
 		right := p.equality()
-		expression = &expr.Logical{Left: expression, Operator: operator, Right: right}
+		expression = &expr.Logical{Left: expression, Operator: operator, Right: right,
+			Range: expression.Span().Through(right.Span())}
 	}
 
 	return expression
@@ -243,7 +260,8 @@ func (p *Parser) equality() expr.Expr {
 	for p.match(token.BangEqual, token.EqualEqual) {
 		operator := p.previous()
 		right := p.comparison()
-		expression = &expr.Binary{Left: expression, Operator: operator, Right: right}
+		expression = &expr.Binary{Left: expression, Operator: operator, Right: right,
+			Range: expression.Span().Through(right.Span())}
 	}
 
 	return expression
@@ -255,7 +273,8 @@ func (p *Parser) comparison() expr.Expr {
 	for p.match(token.Greater, token.GreaterEqual, token.Less, token.LessEqual) {
 		operator := p.previous()
 		right := p.term()
-		expression = &expr.Binary{Left: expression, Operator: operator, Right: right}
+		expression = &expr.Binary{Left: expression, Operator: operator, Right: right,
+			Range: expression.Span().Through(right.Span())}
 	}
 
 	return expression
@@ -267,7 +286,8 @@ func (p *Parser) term() expr.Expr {
 	for p.match(token.Minus, token.Plus) {
 		operator := p.previous()
 		right := p.factor()
-		expression = &expr.Binary{Left: expression, Operator: operator, Right: right}
+		expression = &expr.Binary{Left: expression, Operator: operator, Right: right,
+			Range: expression.Span().Through(right.Span())}
 	}
 
 	return expression
@@ -279,7 +299,8 @@ func (p *Parser) factor() expr.Expr {
 	for p.match(token.Slash, token.Star) {
 		operator := p.previous()
 		right := p.unary()
-		expression = &expr.Binary{Left: expression, Operator: operator, Right: right}
+		expression = &expr.Binary{Left: expression, Operator: operator, Right: right,
+			Range: expression.Span().Through(right.Span())}
 	}
 
 	return expression
@@ -289,7 +310,7 @@ func (p *Parser) unary() expr.Expr {
 	if p.match(token.Bang, token.Minus) {
 		operator := p.previous()
 		right := p.unary()
-		return &expr.Unary{Operator: operator, Right: right}
+		return &expr.Unary{Operator: operator, Right: right, Range: operator.Span.Through(right.Span())}
 	}
 
 	return p.call()
@@ -303,7 +324,7 @@ func (p *Parser) call() expr.Expr {
 			expression = p.finishCall(expression)
 		} else if p.match(token.Dot) {
 			name := p.consume(token.Identifier, "Expect property name after '.'.")
-			expression = &expr.Get{Object: expression, Name: name}
+			expression = &expr.Get{Object: expression, Name: name, Range: expression.Span().Through(name.Span)}
 		} else {
 			break
 		}
@@ -318,7 +339,7 @@ func (p *Parser) finishCall(callee expr.Expr) expr.Expr {
 	if !p.check(token.RightParen) {
 		for {
 			if len(arguments) >= 255 {
-				error(p.peek(), "Can't have more than 255 arguments.")
+				p.error(p.peek(), rt.CodeTooManyArguments, "Can't have more than 255 arguments.")
 			}
 			arguments = append(arguments, p.expression())
 			if !p.match(token.Comma) {
@@ -329,46 +350,48 @@ func (p *Parser) finishCall(callee expr.Expr) expr.Expr {
 
 	paren := p.consume(token.RightParen, "Expect ')' after arguments.")
 
-	return &expr.Call{Callee: callee, Paren: paren, Arguments: arguments}
+	return &expr.Call{Callee: callee, Paren: paren, Arguments: arguments,
+		Range: callee.Span().Through(paren.Span)}
 }
 
 func (p *Parser) primary() expr.Expr {
 	if p.match(token.False) {
-		return &expr.Literal{Value: object.Boolean(false)}
+		return &expr.Literal{Value: object.Boolean(false), Range: p.previous().Span}
 	}
 	if p.match(token.True) {
-		return &expr.Literal{Value: object.Boolean(true)}
+		return &expr.Literal{Value: object.Boolean(true), Range: p.previous().Span}
 	}
 	if p.match(token.Nil) {
-		return &expr.Literal{Value: nil}
+		return &expr.Literal{Value: nil, Range: p.previous().Span}
 	}
 
 	if p.match(token.Number, token.String) {
-		return &expr.Literal{Value: p.previous().Literal}
+		return &expr.Literal{Value: p.previous().Literal, Range: p.previous().Span}
 	}
 
 	if p.match(token.Super) {
 		keyword := p.previous()
 		p.consume(token.Dot, "Expect '.' after 'super'.")
 		method := p.consume(token.Identifier, "Expect superclass method name.")
-		return &expr.Super{Keyword: keyword, Method: method}
+		return &expr.Super{Keyword: keyword, Method: method, Range: p.span(keyword)}
 	}
 
 	if p.match(token.This) {
-		return &expr.This{Keyword: p.previous()}
+		return &expr.This{Keyword: p.previous(), Range: p.previous().Span}
 	}
 
 	if p.match(token.Identifier) {
-		return &expr.Variable{Name: p.previous()}
+		return &expr.Variable{Name: p.previous(), Range: p.previous().Span}
 	}
 
 	if p.match(token.LeftParen) {
+		paren := p.previous()
 		expression := p.expression()
 		p.consume(token.RightParen, "Expect ')' after expression.")
-		return &expr.Grouping{Expression: expression}
+		return &expr.Grouping{Expression: expression, Range: p.span(paren)}
 	}
 
-	panic(error(p.peek(), "Expect expression."))
+	panic(p.error(p.peek(), rt.CodeExpectExpression, "Expect expression."))
 }
 
 func (p *Parser) expression() expr.Expr {
@@ -376,6 +399,13 @@ func (p *Parser) expression() expr.Expr {
 }
 
 func (p *Parser) function(kind string) *Function {
+	// Doc comments sit on the "fun" keyword, or on the name of a method.
+	start := p.peek()
+	if kind == "function" {
+		start = p.previous()
+	}
+	doc := start.Doc
+
 	name := p.consume(token.Identifier, "Expect "+kind+" name.")
 	p.consume(token.LeftParen, "Expect '(' after "+kind+" name.")
 
@@ -384,7 +414,7 @@ func (p *Parser) function(kind string) *Function {
 	if !p.check(token.RightParen) {
 		for {
 			if len(parameters) >= 255 {
-				error(p.peek(), "Can't have more than 255 parameters.")
+				p.error(p.peek(), rt.CodeTooManyParameters, "Can't have more than 255 parameters.")
 			}
 
 			parameters = append(parameters, p.consume(token.Identifier, "Expect parameter name."))
@@ -398,7 +428,7 @@ func (p *Parser) function(kind string) *Function {
 
 	p.consume(token.LeftBrace, "Expect '{' before "+kind+" body.")
 	body := p.block()
-	return &Function{Name: name, Params: parameters, Body: body}
+	return &Function{Name: name, Params: parameters, Body: body, Doc: doc, Range: p.span(start)}
 }
 
 func (p *Parser) block() []Stmt {
@@ -412,8 +442,8 @@ func (p *Parser) block() []Stmt {
 	return statements
 }
 
-func error(token token.Token, message string) ParseError {
-	rt.ErrorToken(token, message)
+func (p *Parser) error(token token.Token, code rt.Code, message string) ParseError {
+	p.diagnostics.ErrorToken(rt.StageParse, code, token, message)
 	return ParseError{message: message}
 }
 
@@ -421,7 +451,7 @@ func (p *Parser) consume(typ token.TokenType, message string) token.Token {
 	if p.check(typ) {
 		return p.advance()
 	}
-	panic(ParseError{message: message})
+	panic(p.error(p.peek(), rt.CodeExpectToken, message))
 }
 
 func (p *Parser) match(types ...token.TokenType) bool {
@@ -450,14 +480,7 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().Type {
-		case token.Class:
-		case token.Fun:
-		case token.Var:
-		case token.For:
-		case token.If:
-		case token.While:
-		case token.Print:
-		case token.Return:
+		case token.Class, token.Fun, token.Var, token.For, token.If, token.While, token.Print, token.Return:
 			return
 		}
 
@@ -466,6 +489,12 @@ func (p *Parser) synchronize() {
 
 }
 
+// span runs from the start of the given token to the end of the most
+// recently consumed one.
+func (p *Parser) span(start token.Token) token.Span {
+	return start.Span.Through(p.previous().Span)
+}
+
 func (p *Parser) advance() token.Token {
 	if !p.isAtEnd() {
 		p.current++
@@ -14,6 +14,7 @@ type Resolver struct {
 	scopes          *stack
 	currentFunction functionType
 	currentClass    classType
+	diagnostics     *rt.Diagnostics
 }
 
 type functionType int
@@ -36,10 +37,16 @@ func NewResolver(interpreter *interpreter.Interpreter) *Resolver {
 		scopes:          newStack(),
 		currentFunction: None,
 		currentClass:    None,
+		diagnostics:     rt.NewDiagnostics(),
 	}
 }
 
-func (r *Resolver) Resolve(statements []stmt.Stmt) {
+func (r *Resolver) Resolve(statements []stmt.Stmt) *rt.Diagnostics {
+	r.resolve(statements)
+	return r.diagnostics
+}
+
+func (r *Resolver) resolve(statements []stmt.Stmt) {
 	for _, statement := range statements {
 		r.resolveStmt(statement)
 	}
@@ -62,12 +69,16 @@ func (r *Resolver) resolveFunction(function *stmt.Function, funcType functionTyp
 		r.declare(param)
 		r.define(param)
 	}
-	r.Resolve(function.Body)
+	r.resolve(function.Body)
 	r.endScope()
 
 	r.currentFunction = enclosingFunction
 }
 
+func (r *Resolver) error(name token.Token, code rt.Code, message string) {
+	r.diagnostics.ErrorToken(rt.StageResolve, code, name, message)
+}
+
 func (r *Resolver) beginScope() {
 	r.scopes.push(make(map[string]bool))
 }
@@ -83,7 +94,7 @@ func (r *Resolver) declare(name token.Token) {
 
 	scope, _ := r.scopes.peek()
 	if _, ok := scope[name.Lexeme]; ok {
-		rt.ErrorToken(name, "Already a variable with this name in this scope.")
+		r.error(name, rt.CodeRedeclaredVariable, "Already a variable with this name in this scope.")
 	}
 	scope[name.Lexeme] = false
 }
@@ -107,7 +118,7 @@ func (r *Resolver) resolveLocal(expr expr.Expr, name token.Token) {
 
 func (r *Resolver) VisitBlockStmt(stmt *stmt.Block) object.Object {
 	r.beginScope()
-	r.Resolve(stmt.Statements)
+	r.resolve(stmt.Statements)
 	r.endScope()
 	return nil
 }
@@ -120,7 +131,7 @@ func (r *Resolver) VisitClassStmt(stmt *stmt.Class) object.Object {
 	r.define(stmt.Name)
 
 	if stmt.Superclass != nil && stmt.Name.Lexeme == stmt.Superclass.Name.Lexeme {
-		rt.ErrorToken(stmt.Superclass.Name, "A class can't inherit from itself.")
+		r.error(stmt.Superclass.Name, rt.CodeSelfInheritance, "A class can't inherit from itself.")
 	}
 
 	if stmt.Superclass != nil {
@@ -185,12 +196,12 @@ func (r *Resolver) VisitPrintStmt(stmt *stmt.Print) object.Object {
 
 func (r *Resolver) VisitReturnStmt(stmt *stmt.Return) object.Object {
 	if r.currentFunction == None {
-		rt.ErrorToken(stmt.Keyword, "Can't return from top-level code.")
+		r.error(stmt.Keyword, rt.CodeTopLevelReturn, "Can't return from top-level code.")
 	}
 
 	if stmt.Value != nil {
 		if r.currentFunction == Initializer {
-			rt.ErrorToken(stmt.Keyword, "Can't return a value from an initializer.")
+			r.error(stmt.Keyword, rt.CodeInitializerReturn, "Can't return a value from an initializer.")
 		}
 		r.resolveExpr(stmt.Value)
 	}
@@ -262,9 +273,9 @@ func (r *Resolver) VisitSetExpr(expr *expr.Set) object.Object {
 
 func (r *Resolver) VisitSuperExpr(expr *expr.Super) object.Object {
 	if r.currentClass == None {
-		rt.ErrorToken(expr.Keyword, "Can't use 'super' outside of a class.")
+		r.error(expr.Keyword, rt.CodeInvalidSuper, "Can't use 'super' outside of a class.")
 	} else if r.currentClass != Subclass {
-		rt.ErrorToken(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+		r.error(expr.Keyword, rt.CodeInvalidSuper, "Can't use 'super' in a class with no superclass.")
 	}
 	r.resolveLocal(expr, expr.Keyword)
 	return nil
@@ -272,7 +283,7 @@ func (r *Resolver) VisitSuperExpr(expr *expr.Super) object.Object {
 
 func (r *Resolver) VisitThisExpr(expr *expr.This) object.Object {
 	if r.currentClass == None {
-		rt.ErrorToken(expr.Keyword, "Can't use 'this' outside of a class.")
+		r.error(expr.Keyword, rt.CodeInvalidThis, "Can't use 'this' outside of a class.")
 		return nil
 	}
 
@@ -289,7 +300,7 @@ func (r *Resolver) VisitVariableExpr(expr *expr.Variable) object.Object {
 	scope, _ := r.scopes.peek()
 	v, ok := scope[expr.Name.Lexeme]
 	if !r.scopes.isEmpty() && ok && v == false {
-		rt.ErrorToken(expr.Name, "Can't read local variable in its own initializer.")
+		r.error(expr.Name, rt.CodeSelfInitializer, "Can't read local variable in its own initializer.")
 	}
 
 	r.resolveLocal(expr, expr.Name)
@@ -0,0 +1,72 @@
+package rt
+
+// Stage names the phase of running a program that produced a diagnostic.
+type Stage string
+
+const (
+	StageScan    Stage = "scan"
+	StageParse   Stage = "parse"
+	StageResolve Stage = "resolve"
+	StageRuntime Stage = "runtime"
+)
+
+// Code identifies the kind of problem a diagnostic reports, so hosts can
+// tell errors apart without matching messages. Codes are stable; messages
+// may change.
+type Code string
+
+// Scan errors.
+const (
+	CodeUnexpectedCharacter Code = "unexpected-character"
+	CodeInvalidUTF8         Code = "invalid-utf8"
+	CodeUnterminatedString  Code = "unterminated-string"
+	CodeUnterminatedComment Code = "unterminated-comment"
+	CodeInvalidNumber       Code = "invalid-number"
+)
+
+// Parse errors.
+const (
+	CodeExpectToken       Code = "expect-token"
+	CodeExpectExpression  Code = "expect-expression"
+	CodeInvalidAssignment Code = "invalid-assignment"
+	CodeTooManyArguments  Code = "too-many-arguments"
+	CodeTooManyParameters Code = "too-many-parameters"
+)
+
+// Resolve errors.
+const (
+	CodeRedeclaredVariable Code = "redeclared-variable"
+	CodeSelfInheritance    Code = "self-inheritance"
+	CodeTopLevelReturn     Code = "top-level-return"
+	CodeInitializerReturn  Code = "initializer-return"
+	CodeInvalidSuper       Code = "invalid-super"
+	CodeInvalidThis        Code = "invalid-this"
+	CodeSelfInitializer    Code = "self-initializer"
+)
+
+// Runtime errors.
+const (
+	CodeUndefinedVariable Code = "undefined-variable"
+	CodeUndefinedProperty Code = "undefined-property"
+	CodeOperandType       Code = "operand-type"
+	CodeNotCallable       Code = "not-callable"
+	CodeArity             Code = "arity"
+	CodeNotInstance       Code = "not-instance"
+	CodeInvalidSuperclass Code = "invalid-superclass"
+	CodeArgumentType      Code = "argument-type"
+	CodeConversion        Code = "conversion"
+	CodeStackOverflow     Code = "stack-overflow"
+	CodeStepLimit         Code = "step-limit"
+	CodeDeadline          Code = "deadline"
+	CodeCancelled         Code = "cancelled"
+	CodeMemoryLimit       Code = "memory-limit"
+	CodeMissingCapability Code = "missing-capability"
+	CodeHost              Code = "host-error"
+	CodeIndexOutOfRange   Code = "index-out-of-range"
+	CodeInvalidFormat     Code = "invalid-format"
+	CodeInvalidJSON       Code = "invalid-json"
+	CodeNestingDepth      Code = "nesting-depth"
+	// CodeNative covers the other failures natives report, such as
+	// popping an empty list or an unknown time zone.
+	CodeNative Code = "native-error"
+)
@@ -0,0 +1,200 @@
+package rt
+
+import (
+	"golox/token"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	SeverityError   Severity = iota
+	SeverityWarning Severity = iota
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "Warning"
+	}
+	return "Error"
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Stage    Stage
+	Code     Code
+	Message  string
+	Span     token.Span
+	// Where describes the offending token, e.g. " at 'x'" or " at end".
+	Where string
+	// Cause is the Go error behind a runtime error, if any.
+	Cause error
+	// Trace lists the calls active when a runtime error was raised,
+	// innermost first.
+	Trace []Frame
+}
+
+// String summarises the diagnostic on one line, without source excerpts.
+func (d Diagnostic) String() string {
+	text := d.Message
+	if d.Stage != StageRuntime {
+		text = d.Severity.String() + d.Where + ": " + d.Message
+	}
+	if d.Span.Line == 0 {
+		return text
+	}
+	return "[line " + position(d.Span) + "] " + text
+}
+
+// Sources maps the Source of a span to the text it was scanned from.
+type Sources map[uint]string
+
+// Format renders the diagnostic the way the command line reports it,
+// quoting the offending line of source when its text is among sources.
+func (d Diagnostic) Format(sources Sources) string {
+	var text string
+	if d.Span.Line == 0 {
+		// Errors raised by the host have no location in the source.
+		return d.Message + "\n"
+	} else if d.Stage == StageRuntime {
+		text = d.Message + "\n[line " + position(d.Span) + "]\n"
+	} else {
+		text = "[line " + position(d.Span) + "] " + d.Severity.String() + d.Where + ": " + d.Message + "\n"
+	}
+	return text + excerpt(sources[d.Span.Source], d.Span) + traceback(d.Trace)
+}
+
+// traceback lists the call chain, folding runs of the same call (as in
+// deep recursion) into a single line.
+func traceback(trace []Frame) string {
+	var text strings.Builder
+	for i := 0; i < len(trace); {
+		frame := trace[i]
+		repeats := 1
+		for i+repeats < len(trace) && trace[i+repeats].Function == frame.Function &&
+			trace[i+repeats].Site.Span == frame.Site.Span {
+			repeats++
+		}
+
+		text.WriteString("  in " + frame.Function + "()")
+		if frame.Site.Line > 0 {
+			text.WriteString(" called at [line " + position(frame.Site.Span) + "]")
+		}
+		if repeats > 1 {
+			text.WriteString(" (" + strconv.Itoa(repeats) + " times)")
+		}
+		text.WriteString("\n")
+		i += repeats
+	}
+	return text.String()
+}
+
+// Diagnostics collects the problems reported while scanning, parsing,
+// resolving and interpreting a program.
+type Diagnostics struct {
+	Items []Diagnostic
+}
+
+func NewDiagnostics() *Diagnostics {
+	return &Diagnostics{Items: make([]Diagnostic, 0)}
+}
+
+func (d *Diagnostics) ErrorSpan(stage Stage, code Code, span token.Span, message string) {
+	d.Items = append(d.Items, Diagnostic{Severity: SeverityError, Stage: stage, Code: code, Message: message,
+		Span: span})
+}
+
+func (d *Diagnostics) ErrorToken(stage Stage, code Code, t token.Token, message string) {
+	where := " at '" + t.Lexeme + "'"
+	if t.Type == token.Eof {
+		where = " at end"
+	}
+	d.Items = append(d.Items, Diagnostic{Severity: SeverityError, Stage: stage, Code: code, Message: message,
+		Span: t.Span, Where: where})
+}
+
+func (d *Diagnostics) ErrorRuntime(error RuntimeError) {
+	d.Items = append(d.Items, Diagnostic{Severity: SeverityError, Stage: StageRuntime, Code: error.Code,
+		Message: error.Message, Span: error.Token.Span, Cause: error.Cause, Trace: error.Trace})
+}
+
+func (d *Diagnostics) Append(other *Diagnostics) {
+	d.Items = append(d.Items, other.Items...)
+}
+
+// HadError reports whether a static error stops the program from running.
+func (d *Diagnostics) HadError() bool {
+	for _, item := range d.Items {
+		if item.Severity == SeverityError && item.Stage != StageRuntime {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Diagnostics) HadRuntimeError() bool {
+	for _, item := range d.Items {
+		if item.Stage == StageRuntime {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes every diagnostic to w, quoting lines from sources.
+func (d *Diagnostics) Print(w io.Writer, sources Sources) {
+	for _, item := range d.Items {
+		_, _ = io.WriteString(w, item.Format(sources))
+	}
+}
+
+func position(span token.Span) string {
+	if span.Column == 0 {
+		return strconv.Itoa(int(span.Line))
+	}
+	return strconv.Itoa(int(span.Line)) + ":" + strconv.Itoa(int(span.Column))
+}
+
+// excerpt quotes the source line containing span and underlines the span
+// with carets. Spans reaching past the line are underlined to its end.
+func excerpt(source string, span token.Span) string {
+	if source == "" || span.Column == 0 || span.Offset > uint(len(source)) {
+		return ""
+	}
+
+	start := strings.LastIndexByte(source[:span.Offset], '\n') + 1
+	end := strings.IndexByte(source[span.Offset:], '\n')
+	if end < 0 {
+		end = len(source)
+	} else {
+		end += int(span.Offset)
+	}
+	line := strings.TrimRight(source[start:end], "\r")
+
+	var pad strings.Builder
+	for _, r := range source[start:span.Offset] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+
+	width := 1
+	if span.EndLine > span.Line {
+		width = utf8.RuneCountInString(line) - int(span.Column) + 1
+	} else if span.EndColumn > span.Column {
+		width = int(span.EndColumn - span.Column)
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	number := strconv.Itoa(int(span.Line))
+	gutter := strings.Repeat(" ", len(number))
+	return " " + number + " | " + line + "\n" +
+		" " + gutter + " | " + pad.String() + strings.Repeat("^", width) + "\n"
+}
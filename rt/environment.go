@@ -3,6 +3,7 @@ package rt
 import (
 	. "golox/object"
 	"golox/token"
+	"sort"
 )
 
 type Environment struct {
@@ -23,7 +24,7 @@ func (e *Environment) Get(name token.Token) Object {
 		return e.Enclosing.Get(name)
 	}
 
-	panic(RuntimeError{Token: name, Message: "Undefined variable '" + name.Lexeme + "'."})
+	panic(RuntimeError{Token: name, Code: CodeUndefinedVariable, Message: "Undefined variable '" + name.Lexeme + "'."})
 }
 
 func (e *Environment) Assign(name token.Token, value Object) {
@@ -37,7 +38,7 @@ func (e *Environment) Assign(name token.Token, value Object) {
 		return
 	}
 
-	panic(RuntimeError{Token: name, Message: "Undefined variable '" + name.Lexeme + "'."})
+	panic(RuntimeError{Token: name, Code: CodeUndefinedVariable, Message: "Undefined variable '" + name.Lexeme + "'."})
 }
 
 func (e *Environment) Define(name string, value Object) {
@@ -59,3 +60,31 @@ func (e *Environment) GetAt(distance int, name string) Object {
 func (e *Environment) AssignAt(distance int, name token.Token, value Object) {
 	e.Ancestor(distance).values[name.Lexeme] = value
 }
+
+// Lookup returns the value bound to name in this environment or any
+// enclosing one, without raising an error when it is missing.
+func (e *Environment) Lookup(name string) (Object, bool) {
+	if value, ok := e.values[name]; ok {
+		return value, true
+	}
+
+	if e.Enclosing != nil {
+		return e.Enclosing.Lookup(name)
+	}
+	return nil, false
+}
+
+// Len counts the variables defined directly in this environment.
+func (e *Environment) Len() int {
+	return len(e.values)
+}
+
+// Names lists the variables defined directly in this environment, sorted.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.values))
+	for name := range e.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,14 +1,39 @@
 package rt
 
 import (
+	"errors"
 	"golox/token"
 )
 
+// Errors a host may test for with errors.Is when execution is stopped.
+var (
+	ErrStepLimit   = errors.New("step limit exceeded")
+	ErrDeadline    = errors.New("deadline exceeded")
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+)
+
+// Frame is an active call: the function being run and the closing
+// parenthesis of the call that entered it.
+type Frame struct {
+	Function string
+	Site     token.Token
+}
+
 type RuntimeError struct {
 	Token   token.Token
+	Code    Code
 	Message string
+	// Cause is the Go error behind the failure, if any.
+	Cause error
+	// Trace lists the calls active when the error was raised, innermost
+	// first.
+	Trace []Frame
 }
 
 func (e *RuntimeError) Error() string {
 	return e.Message
 }
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Cause
+}
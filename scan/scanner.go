@@ -4,56 +4,90 @@ import (
 	"golox/object"
 	"golox/rt"
 	"golox/token"
+	"math"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-var keywords map[string]token.TokenType
-
-func init() {
-	keywords = make(map[string]token.TokenType)
-	keywords["and"] = token.And
-	keywords["class"] = token.Class
-	keywords["else"] = token.Else
-	keywords["false"] = token.False
-	keywords["for"] = token.For
-	keywords["fun"] = token.Fun
-	keywords["if"] = token.If
-	keywords["nil"] = token.Nil
-	keywords["or"] = token.Or
-	keywords["print"] = token.Print
-	keywords["return"] = token.Return
-	keywords["super"] = token.Super
-	keywords["this"] = token.This
-	keywords["true"] = token.True
-	keywords["var"] = token.Var
-	keywords["while"] = token.While
+// keywords is only read after initialisation, so scanners running on
+// different goroutines may share it.
+var keywords = map[string]token.TokenType{
+	"and":    token.And,
+	"class":  token.Class,
+	"else":   token.Else,
+	"false":  token.False,
+	"for":    token.For,
+	"fun":    token.Fun,
+	"if":     token.If,
+	"nil":    token.Nil,
+	"or":     token.Or,
+	"print":  token.Print,
+	"return": token.Return,
+	"super":  token.Super,
+	"this":   token.This,
+	"true":   token.True,
+	"var":    token.Var,
+	"while":  token.While,
 }
 
 type Scanner struct {
-	source  string
-	tokens  []token.Token
-	start   uint
-	current uint
-	line    uint
+	source      string
+	tokens      []token.Token
+	diagnostics *rt.Diagnostics
+	start       uint
+	current     uint
+	line        uint
+	doc         []string
+
+	// lineStart is the offset of the first byte of the current line, and
+	// startLine/startLineStart remember where the current token began.
+	lineStart      uint
+	startLine      uint
+	startLineStart uint
+
+	// sourceID is copied into every span.
+	sourceID uint
 }
 
 func NewScanner(source string) *Scanner {
 	return &Scanner{
-		source:  source,
-		tokens:  make([]token.Token, 0),
-		start:   0,
-		current: 0,
-		line:    1,
+		source:      source,
+		tokens:      make([]token.Token, 0),
+		diagnostics: rt.NewDiagnostics(),
+		start:       0,
+		current:     0,
+		line:        1,
 	}
 }
 
-func (s *Scanner) ScanTokens() []token.Token {
+// SourceID sets the Source recorded in every span the scanner produces.
+func (s *Scanner) SourceID(id uint) *Scanner {
+	s.sourceID = id
+	return s
+}
+
+func (s *Scanner) ScanTokens() ([]token.Token, *rt.Diagnostics) {
+	if strings.HasPrefix(s.source, "#!") {
+		// A shebang line lets scripts be executed directly.
+		for s.peek() != '\n' && !s.isAtEnd() {
+			s.advance()
+		}
+	}
+
 	for !s.isAtEnd() {
 		s.start = s.current
+		s.startLine = s.line
+		s.startLineStart = s.lineStart
 		s.scanToken()
 	}
-	s.tokens = append(s.tokens, token.NewToken(token.Eof, "", nil, s.line))
-	return s.tokens
+
+	s.start = s.current
+	s.startLine = s.line
+	s.startLineStart = s.lineStart
+	s.addTokenTyp(token.Eof)
+	return s.tokens, s.diagnostics
 }
 
 func (s *Scanner) scanToken() {
@@ -70,7 +104,13 @@ func (s *Scanner) scanToken() {
 	case ',':
 		s.addTokenTyp(token.Comma)
 	case '.':
-		s.addTokenTyp(token.Dot)
+		if isDigit(s.peek()) {
+			s.number()
+			s.error(rt.CodeInvalidNumber, "Number literal '"+s.source[s.start:s.current]+
+				"' must start with a digit; write '0"+s.source[s.start:s.current]+"'.")
+		} else {
+			s.addTokenTyp(token.Dot)
+		}
 	case '-':
 		s.addTokenTyp(token.Minus)
 	case '+':
@@ -111,6 +151,9 @@ func (s *Scanner) scanToken() {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+			s.docComment()
+		} else if s.match('*') {
+			s.blockComment()
 		} else {
 			s.addTokenTyp(token.Slash)
 		}
@@ -119,7 +162,7 @@ func (s *Scanner) scanToken() {
 	case '\t':
 		// Ignore whitespace.
 	case '\n':
-		s.line++
+		s.newline()
 	case '"':
 		s.string()
 	default:
@@ -127,8 +170,42 @@ func (s *Scanner) scanToken() {
 			s.number()
 		} else if isAlpha(c) {
 			s.identifier()
+		} else if c == utf8.RuneError && s.current-s.start == 1 {
+			s.error(rt.CodeInvalidUTF8, "Invalid UTF-8 encoding.")
 		} else {
-			rt.ErrorLine(s.line, "Unexpected character.")
+			s.error(rt.CodeUnexpectedCharacter, "Unexpected character '"+string(c)+"'.")
+		}
+	}
+}
+
+// docComment records the text of a "///" comment so it can be attached to
+// the next token. A run of doc comments is joined with newlines.
+func (s *Scanner) docComment() {
+	text := s.source[s.start:s.current]
+	if !strings.HasPrefix(text, "///") || strings.HasPrefix(text, "////") {
+		return
+	}
+	text = strings.TrimPrefix(text[3:], " ")
+	s.doc = append(s.doc, strings.TrimRight(text, "\r"))
+}
+
+// blockComment skips a "/* ... */" comment. Block comments nest, so a
+// commented-out region may itself contain block comments.
+func (s *Scanner) blockComment() {
+	depth := 1
+	for depth > 0 {
+		if s.isAtEnd() {
+			s.error(rt.CodeUnterminatedComment, "Unterminated block comment.")
+			return
+		}
+
+		c := s.advance()
+		if c == '\n' {
+			s.newline()
+		} else if c == '/' && s.match('*') {
+			depth++
+		} else if c == '*' && s.match('/') {
+			depth--
 		}
 	}
 }
@@ -148,32 +225,139 @@ func (s *Scanner) identifier() {
 }
 
 func (s *Scanner) number() {
-	for isDigit(s.peek()) {
-		s.advance()
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			s.advance()
+			s.radixNumber(16, "hexadecimal", isHexDigit)
+			return
+		case 'b', 'B':
+			s.advance()
+			s.radixNumber(2, "binary", isBinaryDigit)
+			return
+		case 'o', 'O':
+			s.advance()
+			s.radixNumber(8, "octal", isOctalDigit)
+			return
+		}
 	}
 
+	ok := s.digits(isDigit)
+
 	if s.peek() == '.' && isDigit(s.peekNext()) {
 		// Consume the "."
 		s.advance()
 
-		for isDigit(s.peek()) {
+		ok = s.digits(isDigit) && ok
+	} else if s.peek() == '.' && !isAlpha(s.peekNext()) {
+		s.advance()
+		s.numberError("Expect digits after '.' in number literal.")
+		return
+	}
+
+	if s.peek() == 'e' || s.peek() == 'E' {
+		s.advance()
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+		if !isDigit(s.peek()) {
+			s.numberError("Expect digits in exponent of number literal.")
+			return
+		}
+		ok = s.digits(isDigit) && ok
+	}
+
+	if !ok || !s.checkNumberEnd("decimal") {
+		return
+	}
+
+	text := strings.ReplaceAll(s.source[s.start:s.current], "_", "")
+	num, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		s.numberError("Number literal is out of range.")
+		return
+	}
+	s.addToken(token.Number, object.Number(num))
+}
+
+// radixNumber scans the digits of a prefixed integer literal such as 0xFF,
+// 0b1010 or 0o17. The prefix has already been consumed.
+func (s *Scanner) radixNumber(base int, name string, valid func(rune) bool) {
+	if s.peek() == '_' {
+		s.numberError("Digit separator '_' must be between digits.")
+		return
+	}
+	if !valid(s.peek()) {
+		if s.checkNumberEnd(name) {
+			s.numberError("Expect digits in " + name + " literal.")
+		}
+		return
+	}
+	if !s.digits(valid) || !s.checkNumberEnd(name) {
+		return
+	}
+
+	var num float64
+	for _, c := range s.source[s.start+2 : s.current] {
+		if c != '_' {
+			num = num*float64(base) + float64(digitValue(c))
+		}
+	}
+	if math.IsInf(num, 0) {
+		s.numberError("Number literal is out of range.")
+		return
 	}
-	num, _ := strconv.ParseFloat(s.source[s.start:s.current], 64)
 	s.addToken(token.Number, object.Number(num))
 }
 
+// digits consumes a run of digits accepted by valid, allowing single '_'
+// separators between them. It reports false after emitting an error for a
+// misplaced separator.
+func (s *Scanner) digits(valid func(rune) bool) bool {
+	for valid(s.peek()) || s.peek() == '_' {
+		if s.advance() == '_' && !valid(s.peek()) {
+			s.numberError("Digit separator '_' must be between digits.")
+			return false
+		}
+	}
+	return true
+}
+
+// checkNumberEnd rejects a literal that runs straight into letters or
+// digits which do not belong to it, like 12abc or 0b102.
+func (s *Scanner) checkNumberEnd(name string) bool {
+	c := s.peek()
+	if !isAlphaNumeric(c) {
+		return true
+	}
+	if isDigit(c) {
+		s.numberError("Invalid digit '" + string(c) + "' in " + name + " literal.")
+	} else {
+		s.numberError("Invalid character '" + string(c) + "' in " + name + " literal.")
+	}
+	return false
+}
+
+// numberError reports a malformed number literal and skips the rest of it,
+// so the parser sees a single placeholder number instead of a cascade of
+// unrelated tokens.
+func (s *Scanner) numberError(message string) {
+	for isAlphaNumeric(s.peek()) || s.peek() == '.' && isDigit(s.peekNext()) {
+		s.advance()
+	}
+	s.error(rt.CodeInvalidNumber, message)
+	s.addToken(token.Number, object.Number(0))
+}
+
 func (s *Scanner) string() {
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+		if s.advance() == '\n' {
+			s.newline()
 		}
-		s.advance()
 	}
 
 	if s.isAtEnd() {
-		rt.ErrorLine(s.line, "Unterminated string.")
+		s.error(rt.CodeUnterminatedString, "Unterminated string.")
 		return
 	}
 
@@ -185,29 +369,35 @@ func (s *Scanner) string() {
 	s.addToken(token.String, object.String(value))
 }
 
-func (s *Scanner) peekNext() byte {
-	if s.current+1 >= uint(len(s.source)) {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+uint(size) >= uint(len(s.source)) {
 		return 0
 	}
-	return s.source[s.current+1]
+	r, _ := utf8.DecodeRuneInString(s.source[s.current+uint(size):])
+	return r
 }
 
-func (s *Scanner) peek() byte {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current]
+	r, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return r
 }
 
-func (s *Scanner) match(expected byte) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if s.source[s.current] != expected {
+	if s.peek() != expected {
 		return false
 	}
 
-	s.current++
+	s.advance()
 	return true
 }
 
@@ -217,27 +407,87 @@ func (s *Scanner) addTokenTyp(typ token.TokenType) {
 
 func (s *Scanner) addToken(typ token.TokenType, literal object.Object) {
 	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, token.NewToken(typ, text, literal, s.line))
+	t := token.NewToken(typ, text, literal, s.startLine)
+	t.Span = s.span()
+	if len(s.doc) > 0 {
+		t.Doc = strings.Join(s.doc, "\n")
+		s.doc = nil
+	}
+	s.tokens = append(s.tokens, t)
+}
+
+func (s *Scanner) error(code rt.Code, message string) {
+	s.diagnostics.ErrorSpan(rt.StageScan, code, s.span(), message)
+}
+
+// span covers the text of the token being scanned.
+func (s *Scanner) span() token.Span {
+	return token.Span{
+		Line:      s.startLine,
+		Column:    uint(utf8.RuneCountInString(s.source[s.startLineStart:s.start])) + 1,
+		EndLine:   s.line,
+		EndColumn: uint(utf8.RuneCountInString(s.source[s.lineStart:s.current])) + 1,
+		Offset:    s.start,
+		End:       s.current,
+		Source:    s.sourceID,
+	}
+}
+
+// newline is called after consuming a '\n'.
+func (s *Scanner) newline() {
+	s.line++
+	s.lineStart = s.current
 }
 
 func (s *Scanner) isAtEnd() bool {
 	return s.current >= uint(len(s.source))
 }
 
-func (s *Scanner) advance() byte {
-	i := s.current
-	s.current += 1
-	return s.source[i]
+// advance consumes the next UTF-8 encoded code point. Invalid bytes decode
+// as utf8.RuneError and are consumed one at a time, which tells them apart
+// from an encoded U+FFFD.
+func (s *Scanner) advance() rune {
+	r, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += uint(size)
+	return r
 }
 
-func isAlpha(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+func isAlpha(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' ||
+		c > unicode.MaxASCII && unicode.IsLetter(c)
 }
 
-func isDigit(c byte) bool {
+func isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func isAlphaNumeric(c byte) bool {
-	return isAlpha(c) || isDigit(c)
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isOctalDigit(c rune) bool {
+	return c >= '0' && c <= '7'
+}
+
+func isBinaryDigit(c rune) bool {
+	return c == '0' || c == '1'
+}
+
+func digitValue(c rune) int {
+	switch {
+	case isDigit(c):
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	default:
+		return int(c-'A') + 10
+	}
+}
+
+// isAlphaNumeric reports whether c may continue an identifier. Besides
+// letters and digits this admits combining marks, so identifiers written
+// with decomposed accents scan as one token.
+func isAlphaNumeric(c rune) bool {
+	return isAlpha(c) || isDigit(c) ||
+		c > unicode.MaxASCII && unicode.In(c, unicode.Nd, unicode.Mn, unicode.Mc)
 }
@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"golox/object"
+	"golox/rt"
+	"golox/token"
+	"testing"
+)
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		{"0", 0},
+		{"42", 42},
+		{"3.25", 3.25},
+		{"1_000_000", 1000000},
+		{"1_0.2_5", 10.25},
+		{"0xff", 255},
+		{"0XFF", 255},
+		{"0xdead_beef", 0xdeadbeef},
+		{"0b1010", 10},
+		{"0B1_0", 2},
+		{"0o17", 15},
+		{"0O7_7", 63},
+		{"1e3", 1000},
+		{"1E3", 1000},
+		{"2.5e-2", 0.025},
+		{"1e+2", 100},
+		{"1_0e1_0", 1e11},
+		{"0x1fffffffffffff", 1<<53 - 1},
+	}
+
+	for _, test := range tests {
+		tokens, diagnostics := NewScanner(test.source).ScanTokens()
+		if diagnostics.HadError() {
+			t.Errorf("%s: unexpected error: %s", test.source, diagnostics.Items[0].Message)
+			continue
+		}
+		if len(tokens) != 2 || tokens[0].Type != token.Number {
+			t.Errorf("%s: scanned %v, want one number", test.source, tokens)
+			continue
+		}
+		if got := tokens[0].Literal; got != object.Number(test.want) {
+			t.Errorf("%s: scanned %v, want %v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestInvalidNumberLiterals(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{"1_", "Digit separator '_' must be between digits."},
+		{"1__0", "Digit separator '_' must be between digits."},
+		{"1_.5", "Digit separator '_' must be between digits."},
+		{"0x_1", "Digit separator '_' must be between digits."},
+		{"0x", "Expect digits in hexadecimal literal."},
+		{"0b", "Expect digits in binary literal."},
+		{"0o", "Expect digits in octal literal."},
+		{"0b102", "Invalid digit '2' in binary literal."},
+		{"0o8", "Invalid digit '8' in octal literal."},
+		{"0xfg", "Invalid character 'g' in hexadecimal literal."},
+		{"12abc", "Invalid character 'a' in decimal literal."},
+		{"1.", "Expect digits after '.' in number literal."},
+		{"1e", "Expect digits in exponent of number literal."},
+		{"1e+", "Expect digits in exponent of number literal."},
+		{".5", "Number literal '.5' must start with a digit; write '0.5'."},
+		{"1e400", "Number literal is out of range."},
+	}
+
+	for _, test := range tests {
+		tokens, diagnostics := NewScanner(test.source).ScanTokens()
+		if len(diagnostics.Items) != 1 {
+			t.Errorf("%s: got %d diagnostics, want 1", test.source, len(diagnostics.Items))
+			continue
+		}
+		if d := diagnostics.Items[0]; d.Code != rt.CodeInvalidNumber || d.Message != test.message {
+			t.Errorf("%s: got %s %q, want %s %q", test.source, d.Code, d.Message, rt.CodeInvalidNumber, test.message)
+		}
+		// A bad literal still scans as a single number, so the parser
+		// doesn't report a cascade of errors after it.
+		if len(tokens) != 2 || tokens[0].Type != token.Number {
+			t.Errorf("%s: scanned %v, want one number", test.source, tokens)
+		}
+	}
+}
+
+// A number followed by a dot and a name is a method call, not a malformed
+// literal.
+func TestNumberBeforeProperty(t *testing.T) {
+	tokens, diagnostics := NewScanner("1.foo").ScanTokens()
+	if diagnostics.HadError() {
+		t.Fatalf("unexpected error: %s", diagnostics.Items[0].Message)
+	}
+	want := []token.TokenType{token.Number, token.Dot, token.Identifier, token.Eof}
+	if len(tokens) != len(want) {
+		t.Fatalf("scanned %v, want %v", tokens, want)
+	}
+	for n, typ := range want {
+		if tokens[n].Type != typ {
+			t.Errorf("token %d: got type %v, want %v", n, tokens[n].Type, typ)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package golox
+
+import (
+	"encoding/json"
+	"fmt"
+	"golox/interpreter"
+	"golox/object"
+	"golox/parser"
+	"golox/scan"
+	"golox/stmt"
+	"golox/token"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// snapshotVersion is bumped whenever the snapshot format changes.
+const snapshotVersion = 1
+
+// snapshot is the file format written by Snapshot. Classes and functions
+// are stored as the source of their declarations, which Restore runs
+// again. Lists, maps and instances live in Objects and are referred to by
+// index, so shared references and cycles survive a round trip.
+type snapshot struct {
+	Version      int                      `json:"version"`
+	Declarations []string                 `json:"declarations"`
+	Objects      []snapshotObject         `json:"objects"`
+	Globals      map[string]snapshotValue `json:"globals"`
+}
+
+type snapshotValue struct {
+	Type   string `json:"type"`
+	Number string `json:"number,omitempty"`
+	String string `json:"string,omitempty"`
+	Bool   bool   `json:"bool,omitempty"`
+	Decl   int    `json:"decl,omitempty"`
+	Ref    int    `json:"ref,omitempty"`
+}
+
+type snapshotObject struct {
+	Kind     string                   `json:"kind"`
+	Class    *snapshotValue           `json:"class,omitempty"`
+	Fields   map[string]snapshotValue `json:"fields,omitempty"`
+	Elements []snapshotValue          `json:"elements,omitempty"`
+	Entries  [][2]snapshotValue       `json:"entries,omitempty"`
+}
+
+// declaration is the source of a top-level class or function declaration,
+// numbered in the order it ran.
+type declaration struct {
+	seq    int
+	source string
+}
+
+// record remembers the source of the top-level declarations in statements
+// so that Snapshot can write them out.
+func (vm *VM) record(source string, statements []stmt.Stmt) {
+	for _, statement := range statements {
+		switch statement.(type) {
+		case *stmt.Class, *stmt.Function:
+			span := statement.Span()
+			vm.declarations[statement] = declaration{seq: len(vm.order), source: source[span.Offset:span.End]}
+			vm.order = append(vm.order, statement)
+		}
+	}
+}
+
+// SnapshotError reports a global whose value cannot be written to a
+// snapshot.
+type SnapshotError struct {
+	Name   string
+	Reason string
+}
+
+func (e *SnapshotError) Error() string {
+	return "Can't snapshot global '" + e.Name + "': " + e.Reason
+}
+
+// Snapshot writes the global variables to w as JSON. Numbers, strings,
+// booleans, nil, lists, maps, instances and top-level classes and functions
+// are supported. Globals still holding the value installed by New or Bind
+// are skipped, since the host provides them again; any other native or Go
+// value is a *SnapshotError.
+func (vm *VM) Snapshot(w io.Writer) error {
+	encoder := &snapshotEncoder{vm: vm, refs: make(map[object.Object]int), decls: make(map[stmt.Stmt]int)}
+	globals := make(map[string]snapshotValue)
+	for _, name := range vm.interpreter.Globals.Names() {
+		value, _ := vm.Get(name)
+		if host, ok := vm.host[name]; ok && host == value {
+			continue
+		}
+
+		encoder.name = name
+		encoded, err := encoder.value(value)
+		if err != nil {
+			return err
+		}
+		globals[name] = encoded
+	}
+
+	data := snapshot{Version: snapshotVersion, Declarations: encoder.sources(globals), Objects: encoder.objects, Globals: globals}
+	out := json.NewEncoder(w)
+	out.SetIndent("", "  ")
+	return out.Encode(data)
+}
+
+// SaveSnapshot writes a snapshot of the globals to the file at path.
+func (vm *VM) SaveSnapshot(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := vm.Snapshot(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Restore reads a snapshot written by Snapshot and defines its globals in
+// the VM, replacing any with the same name. Class and function
+// declarations are run again first, in their original order.
+func (vm *VM) Restore(r io.Reader) error {
+	var data snapshot
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if data.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", data.Version)
+	}
+
+	// Check every declaration before running any, so that a snapshot can't
+	// run arbitrary code.
+	names := make([]string, len(data.Declarations))
+	for n, source := range data.Declarations {
+		name, ok := declarationSource(source)
+		if !ok {
+			return fmt.Errorf("invalid snapshot: declaration %d is not a single class or function", n)
+		}
+		names[n] = name
+	}
+
+	decoder := &snapshotDecoder{data: &data}
+	for n, source := range data.Declarations {
+		if _, err := vm.Eval(source); err != nil {
+			return err
+		}
+		value, _ := vm.Get(names[n])
+		decoder.decls = append(decoder.decls, value)
+	}
+
+	if err := decoder.allocate(); err != nil {
+		return err
+	}
+	if err := decoder.fill(); err != nil {
+		return err
+	}
+
+	globals := make([]string, 0, len(data.Globals))
+	for name := range data.Globals {
+		globals = append(globals, name)
+	}
+	sort.Strings(globals)
+	for _, name := range globals {
+		value, err := decoder.value(data.Globals[name])
+		if err != nil {
+			return err
+		}
+		vm.Set(name, value)
+	}
+	return nil
+}
+
+// LoadSnapshot restores the snapshot in the file at path.
+func (vm *VM) LoadSnapshot(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return vm.Restore(file)
+}
+
+// declarationSource parses source and reports the name it declares if it
+// is exactly one top-level class or function declaration.
+func declarationSource(source string) (string, bool) {
+	tokens, diagnostics := scan.NewScanner(source).ScanTokens()
+	if diagnostics.HadError() {
+		return "", false
+	}
+	statements, diagnostics := parser.NewParser(tokens).Parse()
+	if diagnostics.HadError() || len(statements) != 1 {
+		return "", false
+	}
+	switch statements[0].(type) {
+	case *stmt.Class, *stmt.Function:
+		return declarationName(statements[0]), true
+	}
+	return "", false
+}
+
+func declarationName(statement stmt.Stmt) string {
+	switch statement := statement.(type) {
+	case *stmt.Class:
+		return statement.Name.Lexeme
+	case *stmt.Function:
+		return statement.Name.Lexeme
+	}
+	return ""
+}
+
+type snapshotEncoder struct {
+	vm      *VM
+	name    string
+	objects []snapshotObject
+	refs    map[object.Object]int
+	decls   map[stmt.Stmt]int
+	used    []stmt.Stmt
+}
+
+func (e *snapshotEncoder) fail(reason string) error {
+	return &SnapshotError{Name: e.name, Reason: reason}
+}
+
+func (e *snapshotEncoder) value(value object.Object) (snapshotValue, error) {
+	switch value := value.(type) {
+	case nil:
+		return snapshotValue{Type: "nil"}, nil
+	case object.Number:
+		return snapshotValue{Type: "number", Number: strconv.FormatFloat(float64(value), 'g', -1, 64)}, nil
+	case object.String:
+		return snapshotValue{Type: "string", String: string(value)}, nil
+	case object.Boolean:
+		return snapshotValue{Type: "boolean", Bool: bool(value)}, nil
+	case *interpreter.LoxClass:
+		if value.Superclass != nil {
+			if _, err := e.value(value.Superclass); err != nil {
+				return snapshotValue{}, err
+			}
+		}
+		return e.declaration(value.Declaration, "class '"+value.Name+"'")
+	case *interpreter.LoxFunction:
+		return e.declaration(value.Declaration(), "function '"+value.Declaration().Name.Lexeme+"'")
+	case *interpreter.LoxList, *interpreter.LoxMap, *interpreter.LoxInstance:
+		return e.reference(value)
+	case *interpreter.Native:
+		return snapshotValue{}, e.fail("native function '" + value.Name + "' can't be serialized.")
+	}
+	return snapshotValue{}, e.fail(value.ToString() + " can't be serialized.")
+}
+
+// declaration encodes a class or function by the source of its
+// declaration, which must have run at the top level of a script.
+func (e *snapshotEncoder) declaration(statement stmt.Stmt, what string) (snapshotValue, error) {
+	if index, ok := e.decls[statement]; ok {
+		return snapshotValue{Type: "decl", Decl: index}, nil
+	}
+	if _, ok := e.vm.declarations[statement]; !ok {
+		return snapshotValue{}, e.fail(what + " was not declared at the top level of a script.")
+	}
+
+	e.decls[statement] = len(e.used)
+	e.used = append(e.used, statement)
+	return snapshotValue{Type: "decl", Decl: e.decls[statement]}, nil
+}
+
+// sources returns the declarations in the order they originally ran and
+// renumbers the references to them to match.
+func (e *snapshotEncoder) sources(globals map[string]snapshotValue) []string {
+	order := append([]stmt.Stmt(nil), e.used...)
+	sort.Slice(order, func(a, b int) bool {
+		return e.vm.declarations[order[a]].seq < e.vm.declarations[order[b]].seq
+	})
+
+	renumber := make(map[int]int, len(order))
+	sources := make([]string, len(order))
+	for i, statement := range order {
+		renumber[e.decls[statement]] = i
+		sources[i] = e.vm.declarations[statement].source
+	}
+
+	fix := func(value *snapshotValue) {
+		if value.Type == "decl" {
+			value.Decl = renumber[value.Decl]
+		}
+	}
+	for name, value := range globals {
+		fix(&value)
+		globals[name] = value
+	}
+	for i := range e.objects {
+		object := &e.objects[i]
+		if object.Class != nil {
+			fix(object.Class)
+		}
+		for name, value := range object.Fields {
+			fix(&value)
+			object.Fields[name] = value
+		}
+		for j := range object.Elements {
+			fix(&object.Elements[j])
+		}
+		for j := range object.Entries {
+			fix(&object.Entries[j][0])
+			fix(&object.Entries[j][1])
+		}
+	}
+	return sources
+}
+
+func (e *snapshotEncoder) reference(value object.Object) (snapshotValue, error) {
+	if index, ok := e.refs[value]; ok {
+		return snapshotValue{Type: "ref", Ref: index}, nil
+	}
+
+	index := len(e.objects)
+	e.refs[value] = index
+	e.objects = append(e.objects, snapshotObject{})
+
+	var encoded snapshotObject
+	switch value := value.(type) {
+	case *interpreter.LoxList:
+		encoded.Kind = "list"
+		encoded.Elements = make([]snapshotValue, 0, len(value.Elements))
+		for _, element := range value.Elements {
+			item, err := e.value(element)
+			if err != nil {
+				return snapshotValue{}, err
+			}
+			encoded.Elements = append(encoded.Elements, item)
+		}
+	case *interpreter.LoxMap:
+		encoded.Kind = "map"
+		encoded.Entries = make([][2]snapshotValue, 0, value.Len())
+		for _, key := range value.Keys() {
+			k, err := e.value(key)
+			if err != nil {
+				return snapshotValue{}, err
+			}
+			element, _ := value.Lookup(key)
+			v, err := e.value(element)
+			if err != nil {
+				return snapshotValue{}, err
+			}
+			encoded.Entries = append(encoded.Entries, [2]snapshotValue{k, v})
+		}
+	case *interpreter.LoxInstance:
+		encoded.Kind = "instance"
+		class, err := e.value(value.Class())
+		if err != nil {
+			return snapshotValue{}, err
+		}
+		encoded.Class = &class
+		encoded.Fields = make(map[string]snapshotValue, len(value.Fields()))
+		for name, field := range value.Fields() {
+			item, err := e.value(field)
+			if err != nil {
+				return snapshotValue{}, err
+			}
+			encoded.Fields[name] = item
+		}
+	}
+	e.objects[index] = encoded
+	return snapshotValue{Type: "ref", Ref: index}, nil
+}
+
+type snapshotDecoder struct {
+	data    *snapshot
+	decls   []object.Object
+	objects []object.Object
+}
+
+// allocate creates every list, map and instance before any is filled in,
+// so that references between them can be resolved in any order.
+func (d *snapshotDecoder) allocate() error {
+	d.objects = make([]object.Object, len(d.data.Objects))
+	for i, encoded := range d.data.Objects {
+		switch encoded.Kind {
+		case "list":
+			d.objects[i] = interpreter.NewLoxList(make([]object.Object, 0, len(encoded.Elements)))
+		case "map":
+			d.objects[i] = interpreter.NewLoxMap()
+		case "instance":
+			if encoded.Class == nil {
+				return fmt.Errorf("invalid snapshot: instance without a class")
+			}
+			class, err := d.value(*encoded.Class)
+			if err != nil {
+				return err
+			}
+			loxClass, ok := class.(*interpreter.LoxClass)
+			if !ok {
+				return fmt.Errorf("invalid snapshot: instance of a value that is not a class")
+			}
+			d.objects[i] = interpreter.NewLoxInstance(loxClass)
+		default:
+			return fmt.Errorf("invalid snapshot: unknown object kind %q", encoded.Kind)
+		}
+	}
+	return nil
+}
+
+func (d *snapshotDecoder) fill() error {
+	for i, encoded := range d.data.Objects {
+		switch target := d.objects[i].(type) {
+		case *interpreter.LoxList:
+			for _, element := range encoded.Elements {
+				value, err := d.value(element)
+				if err != nil {
+					return err
+				}
+				target.Elements = append(target.Elements, value)
+			}
+		case *interpreter.LoxMap:
+			for _, entry := range encoded.Entries {
+				key, err := d.value(entry[0])
+				if err != nil {
+					return err
+				}
+				value, err := d.value(entry[1])
+				if err != nil {
+					return err
+				}
+				target.Put(key, value)
+			}
+		case *interpreter.LoxInstance:
+			for name, field := range encoded.Fields {
+				value, err := d.value(field)
+				if err != nil {
+					return err
+				}
+				target.Set(token.Token{Lexeme: name}, value)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *snapshotDecoder) value(value snapshotValue) (object.Object, error) {
+	switch value.Type {
+	case "nil":
+		return nil, nil
+	case "number":
+		number, err := strconv.ParseFloat(value.Number, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		return object.Number(number), nil
+	case "string":
+		return object.String(value.String), nil
+	case "boolean":
+		return object.Boolean(value.Bool), nil
+	case "decl":
+		if value.Decl < 0 || value.Decl >= len(d.decls) {
+			return nil, fmt.Errorf("invalid snapshot: declaration %d out of range", value.Decl)
+		}
+		return d.decls[value.Decl], nil
+	case "ref":
+		if value.Ref < 0 || value.Ref >= len(d.objects) {
+			return nil, fmt.Errorf("invalid snapshot: object %d out of range", value.Ref)
+		}
+		return d.objects[value.Ref], nil
+	}
+	return nil, fmt.Errorf("invalid snapshot: unknown value type %q", value.Type)
+}
@@ -0,0 +1,108 @@
+package golox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	vm := New(Options{})
+	_, err := vm.Eval(`
+class Point {
+  init(x, y) { this.x = x; this.y = y; }
+  sum() { return this.x + this.y; }
+}
+class Point3 < Point {
+  init(x, y, z) { super.init(x, y); this.z = z; }
+  sum() { return super.sum() + this.z; }
+}
+fun double(n) { return n * 2; }
+
+var number = 1.5;
+var text = "hi";
+var flag = true;
+var nothing = nil;
+var point = Point3(1, 2, 3);
+var list = List();
+list.push(point);
+list.push(list);
+var map = Map();
+map.set("list", list);
+map.set(1, double);
+var alias = list;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var saved bytes.Buffer
+	if err := vm.Snapshot(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	restored := New(Options{Stdout: &out})
+	if err := restored.Restore(&saved); err != nil {
+		t.Fatal(err)
+	}
+	_, err = restored.Eval(`
+print number;
+print text;
+print flag;
+print nothing;
+print point.sum();
+print point.x;
+print list.get(1) == list;
+print alias == list;
+print map.get("list") == list;
+print map.get(1)(21);
+print Point(4, 5).sum();
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1.5\nhi\ntrue\nnil\n6\n1\ntrue\ntrue\ntrue\n42\n9\n"
+	if got := out.String(); got != want {
+		t.Errorf("restored VM printed:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRestoreRejectsMalformedSnapshots(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot string
+		err      string
+	}{
+		{"json", `{"version":`, "invalid snapshot"},
+		{"version", `{"version":2}`, "unsupported snapshot version 2"},
+		{"statement", `{"version":1,"declarations":["print \"side effect\";"]}`,
+			"declaration 0 is not a single class or function"},
+		{"two declarations", `{"version":1,"declarations":["fun a() {} fun b() {}"]}`,
+			"declaration 0 is not a single class or function"},
+		{"declaration and statement", `{"version":1,"declarations":["fun a() {} print \"side effect\";"]}`,
+			"declaration 0 is not a single class or function"},
+		{"syntax", `{"version":1,"declarations":["fun a( {}"]}`,
+			"declaration 0 is not a single class or function"},
+		{"empty", `{"version":1,"declarations":[""]}`,
+			"declaration 0 is not a single class or function"},
+		{"decl index", `{"version":1,"globals":{"f":{"type":"decl","decl":3}}}`, "declaration 3 out of range"},
+		{"ref index", `{"version":1,"globals":{"l":{"type":"ref","ref":-1}}}`, "object -1 out of range"},
+		{"kind", `{"version":1,"objects":[{"kind":"set"}]}`, `unknown object kind "set"`},
+		{"instance class", `{"version":1,"objects":[{"kind":"instance","class":{"type":"number","number":"1"}}]}`,
+			"instance of a value that is not a class"},
+		{"value type", `{"version":1,"globals":{"x":{"type":"date"}}}`, `unknown value type "date"`},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		vm := New(Options{Stdout: &out})
+		err := vm.Restore(strings.NewReader(test.snapshot))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want one containing %q", test.name, err, test.err)
+		}
+		if out.Len() > 0 {
+			t.Errorf("%s: restoring printed %q", test.name, out.String())
+		}
+	}
+}
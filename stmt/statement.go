@@ -8,6 +8,7 @@ import (
 
 type Stmt interface {
 	Accept(v Visitor) object.Object
+	Span() token.Span
 }
 
 type Visitor interface {
@@ -24,81 +25,129 @@ type Visitor interface {
 
 type Block struct {
 	Statements []Stmt
+	Range      token.Span
 }
 
 func (b *Block) Accept(v Visitor) object.Object {
 	return v.VisitBlockStmt(b)
 }
 
+func (b *Block) Span() token.Span {
+	return b.Range
+}
+
 type Class struct {
 	Name       token.Token
 	Superclass *expr.Variable
 	Methods    []*Function
+	Doc        string
+	Range      token.Span
 }
 
 func (c *Class) Accept(v Visitor) object.Object {
 	return v.VisitClassStmt(c)
 }
 
+func (c *Class) Span() token.Span {
+	return c.Range
+}
+
 type Expression struct {
 	Expression expr.Expr
+	Range      token.Span
 }
 
 func (e *Expression) Accept(v Visitor) object.Object {
 	return v.VisitExpressionStmt(e)
 }
 
+func (e *Expression) Span() token.Span {
+	return e.Range
+}
+
 type Function struct {
 	Name   token.Token
 	Params []token.Token
 	Body   []Stmt
+	Doc    string
+	Range  token.Span
 }
 
 func (f *Function) Accept(v Visitor) object.Object {
 	return v.VisitFunctionStmt(f)
 }
 
+func (f *Function) Span() token.Span {
+	return f.Range
+}
+
 type If struct {
 	Condition  expr.Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
+	Range      token.Span
 }
 
 func (i *If) Accept(v Visitor) object.Object {
 	return v.VisitIfStmt(i)
 }
 
+func (i *If) Span() token.Span {
+	return i.Range
+}
+
 type Print struct {
 	Expression expr.Expr
+	Range      token.Span
 }
 
 func (p *Print) Accept(v Visitor) object.Object {
 	return v.VisitPrintStmt(p)
 }
 
+func (p *Print) Span() token.Span {
+	return p.Range
+}
+
 type Return struct {
 	Keyword token.Token
 	Value   expr.Expr
+	Range   token.Span
 }
 
 func (r *Return) Accept(v Visitor) object.Object {
 	return v.VisitReturnStmt(r)
 }
 
+func (r *Return) Span() token.Span {
+	return r.Range
+}
+
 type Var struct {
 	Name        token.Token
 	Initializer expr.Expr
+	Range       token.Span
 }
 
 func (va *Var) Accept(v Visitor) object.Object {
 	return v.VisitVarStmt(va)
 }
 
+func (va *Var) Span() token.Span {
+	return va.Range
+}
+
 type While struct {
+	Keyword   token.Token
 	Condition expr.Expr
 	Body      Stmt
+	Range     token.Span
 }
 
 func (w *While) Accept(v Visitor) object.Object {
 	return v.VisitWhileStmt(w)
 }
+
+func (w *While) Span() token.Span {
+	return w.Range
+}
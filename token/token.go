@@ -54,19 +54,51 @@ const (
 	Eof TokenType = iota
 )
 
+// Span locates a piece of source text. Lines and columns start at 1 and
+// columns count code points; offsets are byte offsets into the source.
+// EndColumn and End point just past the last character. Source tells
+// apart the texts a host scans, so that offsets are read against the
+// right one.
+type Span struct {
+	Line      uint
+	Column    uint
+	EndLine   uint
+	EndColumn uint
+	Offset    uint
+	End       uint
+	Source    uint
+}
+
+// Through returns the span running from the start of s to the end of end.
+// A zero span, as carried by synthesized nodes, leaves the other unchanged.
+func (s Span) Through(end Span) Span {
+	if s.Line == 0 {
+		return end
+	}
+	if end.Line == 0 {
+		return s
+	}
+	s.EndLine = end.EndLine
+	s.EndColumn = end.EndColumn
+	s.End = end.End
+	return s
+}
+
 type Token struct {
 	Type    TokenType
 	Lexeme  string
 	Literal object.Object
-	Line    uint
+	Span
+	// Doc holds the text of any "///" comments directly before the token.
+	Doc string
 }
 
 func NewToken(typ TokenType, lexeme string, literal object.Object, line uint) Token {
 	return Token{
-		typ,
-		lexeme,
-		literal,
-		line,
+		Type:    typ,
+		Lexeme:  lexeme,
+		Literal: literal,
+		Span:    Span{Line: line, EndLine: line},
 	}
 }
 